@@ -0,0 +1,143 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"encoding/binary"
+	"os"
+	"sync"
+	"time"
+)
+
+// Identity of a file in states - path is relative to tree root
+type changeKey struct {
+	tree string
+	path string
+}
+
+// Last known state of a file and number of its changes
+type fileChangeState struct {
+	modTimeNano int64
+	size        int64
+	hasIdentity bool
+	device      uint64
+	inode       uint64
+	hasDigest   bool
+	digest      uint64
+	// time file was first seen by exporter
+	firstSeenNano int64
+
+	modifications  uint64
+	contentChanges uint64
+	truncations    uint64
+	replacements   uint64
+}
+
+// State of files observed in a scrape
+type fileObservation struct {
+	fileinfo  os.FileInfo
+	hasDigest bool
+	digest    uint64
+}
+
+// digest of content from crc32 or first hash - false if content was not hashed
+func (r *contentResult) changeDigest() (uint64, bool) {
+	if r.hasCRC32 {
+		return uint64(r.crc32), true
+	}
+	// digests have at least 64 bits
+	if len(r.digests) != 0 {
+		return binary.BigEndian.Uint64(r.digests[0].digest), true
+	}
+	return 0, false
+}
+
+// States of files between scrapes - kept across reloads
+type changeStates struct {
+	mutex  sync.Mutex
+	states map[changeKey]*fileChangeState
+
+	// serialize writes of state file
+	saveMutex sync.Mutex
+}
+
+func newChangeStates() *changeStates {
+	return &changeStates{states: make(map[changeKey]*fileChangeState)}
+}
+
+// count changes of file since its last observation - counters are 0 when file is first seen
+func (s *changeStates) update(key changeKey, observed fileObservation) fileChangeState {
+	current := fileChangeState{
+		modTimeNano: observed.fileinfo.ModTime().UnixNano(),
+		size:        observed.fileinfo.Size(),
+		hasDigest:   observed.hasDigest,
+		digest:      observed.digest,
+	}
+	current.device, current.inode, current.hasIdentity = fileIdentity(observed.fileinfo)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	state, found := s.states[key]
+	if !found {
+		current.firstSeenNano = time.Now().UnixNano()
+		s.states[key] = &current
+		return current
+	}
+	if current.modTimeNano != state.modTimeNano {
+		state.modifications++
+	}
+	if current.size < state.size {
+		state.truncations++
+	}
+	if current.hasIdentity && state.hasIdentity && (current.device != state.device || current.inode != state.inode) {
+		state.replacements++
+	}
+	if current.hasDigest && state.hasDigest && current.digest != state.digest {
+		state.contentChanges++
+	}
+	state.modTimeNano = current.modTimeNano
+	state.size = current.size
+	state.hasIdentity = current.hasIdentity
+	state.device = current.device
+	state.inode = current.inode
+	// digest is kept when content is not read
+	if current.hasDigest {
+		state.hasDigest = true
+		state.digest = current.digest
+	}
+	return *state
+}
+
+// remove states of files of trees which no longer exist
+func (s *changeStates) pruneTrees(trees map[string]*treeCollector) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for key := range s.states {
+		if _, found := trees[key.tree]; !found {
+			delete(s.states, key)
+		}
+	}
+}
+
+// remove states of files of tree which were not seen
+func (s *changeStates) prune(tree string, seen map[string]struct{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for key := range s.states {
+		if _, found := seen[key.path]; key.tree == tree && !found {
+			delete(s.states, key)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// file info without identity
+type changedFileInfo struct {
+	fakeFileInfo
+}
+
+func (f changedFileInfo) Sys() any { return nil }
+
+func changedFile(modTime time.Time, size int64) changedFileInfo {
+	return changedFileInfo{fakeFileInfo{size: size, modTime: modTime}}
+}
+
+func TestChangeStates_ShouldCountChangesSinceLastObservation(t *testing.T) {
+	states := newChangeStates()
+	key := changeKey{tree: "tree", path: "file.log"}
+	start := time.Unix(1000, 0)
+
+	state := states.update(key, fileObservation{fileinfo: changedFile(start, 100), hasDigest: true, digest: 1})
+	if state.modifications != 0 || state.truncations != 0 || state.contentChanges != 0 {
+		t.Errorf("File first seen has changes %+v", state)
+	}
+	state = states.update(key, fileObservation{fileinfo: changedFile(start.Add(time.Second), 50), hasDigest: true, digest: 2})
+	state = states.update(key, fileObservation{fileinfo: changedFile(start.Add(time.Second), 50)})
+	state = states.update(key, fileObservation{fileinfo: changedFile(start.Add(2*time.Second), 80), hasDigest: true, digest: 2})
+	if state.modifications != 2 {
+		t.Errorf("File has %d modifications instead of 2", state.modifications)
+	}
+	if state.truncations != 1 {
+		t.Errorf("File has %d truncations instead of 1", state.truncations)
+	}
+	if state.contentChanges != 1 {
+		t.Errorf("File has %d content changes instead of 1", state.contentChanges)
+	}
+}
+
+func TestChangeStates_ShouldPruneUnseenFiles(t *testing.T) {
+	states := newChangeStates()
+	info := changedFile(time.Unix(1000, 0), 100)
+	states.update(changeKey{tree: "tree1", path: "kept"}, fileObservation{fileinfo: info})
+	states.update(changeKey{tree: "tree1", path: "removed"}, fileObservation{fileinfo: info})
+	states.update(changeKey{tree: "tree2", path: "removed"}, fileObservation{fileinfo: info})
+
+	states.prune("tree1", map[string]struct{}{"kept": {}})
+
+	if _, found := states.states[changeKey{tree: "tree1", path: "kept"}]; !found {
+		t.Errorf("Seen file was pruned")
+	}
+	if _, found := states.states[changeKey{tree: "tree1", path: "removed"}]; found {
+		t.Errorf("Unseen file was not pruned")
+	}
+	if _, found := states.states[changeKey{tree: "tree2", path: "removed"}]; !found {
+		t.Errorf("File of other tree was pruned")
+	}
+}
+
+func TestCollectTree_ShouldKeepChangeStatesWhenFilesLimited(t *testing.T) {
+	root := t.TempDir()
+	createFiles(t, root, map[string]int{"a.log": 1, "b.log": 1})
+	enabled := true
+	for maxFiles, kept := range map[int]bool{0: false, 1: true} {
+		cfg := configContent{}
+		cfg.Exporter.TreeRoot = &root
+		cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}
+		cfg.Exporter.Files[0].MaxFiles = &maxFiles
+		cfg.Exporter.Files[0].EnableChangeMetrics = &enabled
+		if err := cfg.validate(); err != nil {
+			t.Fatal(err)
+		}
+		states := newChangeStates()
+		states.update(changeKey{path: "gone.log"}, fileObservation{fileinfo: changedFile(time.Unix(1000, 0), 100)})
+		collector := cfg.generateCollector(*slog.Default())
+		collector.useChangeStates(states)
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(&scrapeCollector{ctx: context.Background(), collector: collector})
+		if _, err := registry.Gather(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, found := states.states[changeKey{path: "gone.log"}]; found != kept {
+			t.Errorf("State of unseen file kept is %v with max files %d", found, maxFiles)
+		}
+	}
+}
+
+func TestChangeDigest_ShouldUseConfiguredDigest(t *testing.T) {
+	if _, found := (&contentResult{lineNb: 3}).changeDigest(); found {
+		t.Error("Digest found without crc32 or hash")
+	}
+	if digest, found := (&contentResult{hasCRC32: true, crc32: 42}).changeDigest(); !found || digest != 42 {
+		t.Errorf("Digest %d is not crc32", digest)
+	}
+	result := contentResult{digests: []contentDigest{{algorithm: "md5", digest: []byte{0, 0, 0, 0, 0, 0, 0, 1, 2}}}}
+	if digest, found := result.changeDigest(); !found || digest != 1 {
+		t.Errorf("Digest %d is not first bits of hash", digest)
+	}
+}
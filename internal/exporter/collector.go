@@ -15,13 +15,27 @@
 package exporter
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
 	"hash/crc32"
 	"io"
+	"io/fs"
 	"log/slog"
+	"maps"
 	"os"
 	"path"
+	"path/filepath"
+	"regexp"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -63,6 +77,233 @@ var (
 		Name:      "line_number",
 		Help:      "Number of lines in file",
 	}
+	fileAccessTimeSecondsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "access_time_seconds",
+		Help:      "Last access time of file in epoch time",
+	}
+	fileChangeTimeSecondsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "change_time_seconds",
+		Help:      "Last status change time of file in epoch time",
+	}
+	fileBirthTimeSecondsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "birth_time_seconds",
+		Help:      "Creation time of file in epoch time",
+	}
+	fileNlinkOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "nlink",
+		Help:      "Number of hard links to file",
+	}
+	fileAllocatedBytesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "allocated_bytes",
+		Help:      "Size of disk space allocated to file in bytes",
+	}
+	fileHashInfoOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "hash_info",
+		Help:      "Digest of file content",
+	}
+	fileHashTruncatedOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "hash_truncated",
+		Help:      "First 48 bits of digest of file content",
+	}
+	fileMatchLinesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "match_lines",
+		Help:      "Number of lines of file content matching regex",
+	}
+	fileLastMatchLineOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "last_match_line",
+		Help:      "Line number of last line of file content matching regex",
+	}
+	fileParseErrorOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "parse_error",
+		Help:      "Whether file content could not be parsed as a document",
+	}
+	fileSelectorInfoOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "selector_info",
+		Help:      "Text value selected in file content",
+	}
+	fileDirEntriesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "dir",
+		Name:      "entries",
+		Help:      "Number of entries in directory",
+	}
+	fileDirSizeBytesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "dir",
+		Name:      "size_bytes",
+		Help:      "Apparent size in bytes of files in directory and its subdirectories",
+	}
+	fileExistsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Name:      "exists",
+		Help:      "Whether expected file exists",
+	}
+	fileExpectedMissingOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "expected",
+		Name:      "missing",
+		Help:      "Number of missing expected files of group of files",
+	}
+	filesystemSizeBytesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "filesystem",
+		Name:      "size_bytes",
+		Help:      "Size in bytes of filesystem of tree",
+	}
+	filesystemAvailBytesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "filesystem",
+		Name:      "avail_bytes",
+		Help:      "Available bytes on filesystem of tree",
+	}
+	filesystemFilesFreeOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "filesystem",
+		Name:      "files_free",
+		Help:      "Number of free inodes on filesystem of tree",
+	}
+	fileSymlinkInfoOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "symlink_info",
+		Help:      "Target of symbolic link",
+	}
+	fileSymlinkBrokenOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "symlink_broken",
+		Help:      "Whether target of symbolic link cannot be found",
+	}
+	fileGlobLimitReachedOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "glob",
+		Name:      "limit_reached",
+		Help:      "Whether matching of pattern stopped at maximum number of files",
+	}
+	fileUncompressedBytesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "uncompressed_bytes",
+		Help:      "Size in bytes of decompressed file content",
+	}
+	fileIntegrityErrorOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "integrity_error",
+		Help:      "Whether compressed file content is corrupted",
+	}
+	fileContentTypeInfoOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "type_info",
+		Help:      "Type of file content detected from its first bytes",
+	}
+	fileModificationsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "modifications_total",
+		Help:      "Number of changes of modification time of file",
+	}
+	fileFirstSeenOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "first_seen_seconds",
+		Help:      "Time file was first seen by exporter in epoch time",
+	}
+	fileContentChangesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "changes_total",
+		Help:      "Number of changes of content of file",
+	}
+	fileTruncationsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "truncations_total",
+		Help:      "Number of times size of file shrank",
+	}
+	fileReplacementsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "replacements_total",
+		Help:      "Number of times file was replaced by another file",
+	}
+	fileContentSkippedOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "content",
+		Name:      "skipped",
+		Help:      "Whether content metrics of file are skipped",
+	}
+	fileGlobSizeBytesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "glob",
+		Name:      "size_bytes",
+		Help:      "Total size in bytes of files matching pattern",
+	}
+	fileGlobLargestSizeBytesOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "glob",
+		Name:      "largest_size_bytes",
+		Help:      "Size in bytes of largest file matching pattern",
+	}
+	fileGlobModifTimeMinSecondsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "glob",
+		Name:      "modif_time_min_seconds",
+		Help:      "Oldest modification time of files matching pattern in epoch time",
+	}
+	fileGlobModifTimeMaxSecondsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "glob",
+		Name:      "modif_time_max_seconds",
+		Help:      "Newest modification time of files matching pattern in epoch time",
+	}
+	fileGlobModifTimeSumSecondsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "glob",
+		Name:      "modif_time_sum_seconds",
+		Help:      "Sum of modification times of files matching pattern in epoch time",
+	}
+	fileGlobOldestAgeSecondsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "glob",
+		Name:      "oldest_age_seconds",
+		Help:      "Age of least recently modified file matching pattern",
+	}
+	fileGlobNewestAgeSecondsOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "glob",
+		Name:      "newest_age_seconds",
+		Help:      "Age of most recently modified file matching pattern",
+	}
+	fileStatInfoOpts = prometheus.Opts{
+		Namespace: namespace,
+		Subsystem: "stat",
+		Name:      "info",
+		Help:      "Type, permissions and owner of file",
+	}
 )
 
 // Transform opts into desc
@@ -74,37 +315,181 @@ func optsToDesc(opts *prometheus.Opts, labels []string) *prometheus.Desc {
 		opts.ConstLabels)
 }
 
+// Handling of symbolic links
+const (
+	symlinksFollow = "follow"
+	symlinksSkip   = "skip"
+	symlinksReport = "report"
+)
+
+// reason of skipping content metrics of file
+const contentSkippedTooLarge = "too_large"
+
+// stops matching files of pattern at maximum number of files
+var errGlobLimitReached = errors.New("maximum number of files reached")
+
 // Collector compute metrics for each file matching the patterns in tree
 type fileStatCollector struct {
-	enableCRC32Metric  bool
-	enableLineNbMetric bool
-	labels             []string
+	enableCRC32Metric          bool
+	enableLineNbMetric         bool
+	enableAccessTimeMetric     bool
+	enableChangeTimeMetric     bool
+	enableBirthTimeMetric      bool
+	enableNlinkMetric          bool
+	enableAllocatedBytesMetric bool
+	enableStatInfoMetric       bool
+	aggregateOnly              bool
+	hashAlgorithms             []string
+	enableHashTruncatedMetric  bool
+	lineMatchers               []lineMatcher
+	enableLastMatchLineMetric  bool
+	extractors                 []*valueExtractor
+	contentFormat              string
+	selectors                  []*documentSelector
+	symlinks                   string
+	enableFilesystemMetric     bool
+	includeDirectories         bool
+	dirSizeMaxDepth            int
+	maxFiles                   int
+	maxDepth                   int
+	maxContentBytes            int64
+	decompress                 string
+	enableContentTypeMetric    bool
+	enableChangeMetrics        bool
+	labels                     []string
+
+	treeRoot        string
+	literalTreeRoot bool
+
+	// identify config of content metrics in cache
+	contentFingerprint uint64
 
-	treeRoot string
+	filesPatterns   []string
+	excludePatterns []string
+	expectedPaths   []string
 
-	filesPatterns []string
+	pathLabelsRegex        *regexp.Regexp
+	pathLabelGroups        []int
+	dropPathLabelsMismatch bool
 }
 
 // Collector compute metrics for each tree
 type treeCollector struct {
+	name       string
 	collectors []fileStatCollector
 }
 
+// Files matching an expanded pattern of a collector
+type patternGlob struct {
+	collector   *fileStatCollector
+	pattern     string
+	basepath    string
+	patternRoot string
+	patternPart string
+
+	matches      []string
+	limitReached bool
+	// matches are unknown after error
+	failed bool
+	// index of matching files in tree
+	files []int
+}
+
+// File of tree collected with config of first matching pattern
+type treeFile struct {
+	collector    *fileStatCollector
+	filePath     string
+	realFilePath string
+	labels       []string
+	stats        *treeScrapeStats
+	dirSizes     *dirSizeCache
+
+	isProcessed bool
+	fileinfo    os.FileInfo
+	// digest of content used for change metrics - from crc32 or hash metrics
+	hasDigest bool
+	digest    uint64
+}
+
+// Expanded expected path of a collector
+type expectedFile struct {
+	collector    *fileStatCollector
+	filePath     string
+	realFilePath string
+
+	checked bool
+	exists  bool
+}
+
 // Files collector
 type filesCollector struct {
-	trees  map[string]*treeCollector
-	common []string
+	trees      map[string]*treeCollector
+	treeLabels []string
+	common     []string
+	fileCommon []string
+	workers    *workerPool
+
+	fileMatchingGlobNbDesc    *prometheus.Desc
+	fileSizeBytesDesc         *prometheus.Desc
+	fileModifTimeSecondsDesc  *prometheus.Desc
+	fileCRC32HashDesc         *prometheus.Desc
+	lineNbMetricDesc          *prometheus.Desc
+	fileAccessTimeSecondsDesc *prometheus.Desc
+	fileChangeTimeSecondsDesc *prometheus.Desc
+	fileBirthTimeSecondsDesc  *prometheus.Desc
+	fileNlinkDesc             *prometheus.Desc
+	fileAllocatedBytesDesc    *prometheus.Desc
+	fileStatInfoDesc          *prometheus.Desc
+	fileHashInfoDesc          *prometheus.Desc
+	fileHashTruncatedDesc     *prometheus.Desc
+	fileMatchLinesDesc        *prometheus.Desc
+	fileLastMatchLineDesc     *prometheus.Desc
+	fileParseErrorDesc        *prometheus.Desc
+	fileSelectorInfoDesc      *prometheus.Desc
+	fileDirEntriesDesc        *prometheus.Desc
+	fileDirSizeBytesDesc      *prometheus.Desc
+	fileGlobLimitReachedDesc  *prometheus.Desc
+	fileContentSkippedDesc    *prometheus.Desc
+	fileContentTypeInfoDesc   *prometheus.Desc
+	fileModificationsDesc     *prometheus.Desc
+	fileFirstSeenDesc         *prometheus.Desc
+	fileContentChangesDesc    *prometheus.Desc
+	fileTruncationsDesc       *prometheus.Desc
+	fileReplacementsDesc      *prometheus.Desc
+	fileUncompressedBytesDesc *prometheus.Desc
+	fileIntegrityErrorDesc    *prometheus.Desc
+	fileExistsDesc            *prometheus.Desc
+	fileExpectedMissingDesc   *prometheus.Desc
+	filesystemSizeBytesDesc   *prometheus.Desc
+	filesystemAvailBytesDesc  *prometheus.Desc
+	filesystemFilesFreeDesc   *prometheus.Desc
+	fileSymlinkInfoDesc       *prometheus.Desc
+	fileSymlinkBrokenDesc     *prometheus.Desc
+	valueDescs                map[string]*prometheus.Desc
+
+	fileGlobSizeBytesDesc           *prometheus.Desc
+	fileGlobLargestSizeBytesDesc    *prometheus.Desc
+	fileGlobModifTimeMinSecondsDesc *prometheus.Desc
+	fileGlobModifTimeMaxSecondsDesc *prometheus.Desc
+	fileGlobModifTimeSumSecondsDesc *prometheus.Desc
+	fileGlobOldestAgeSecondsDesc    *prometheus.Desc
+	fileGlobNewestAgeSecondsDesc    *prometheus.Desc
+
+	contentCache *contentCache
+	ownerNames   *ownerNames
+	scrapeStats  *scrapeStats
+	changeStates *changeStates
 
-	fileMatchingGlobNbDesc   *prometheus.Desc
-	fileSizeBytesDesc        *prometheus.Desc
-	fileModifTimeSecondsDesc *prometheus.Desc
-	fileCRC32HashDesc        *prometheus.Desc
-	lineNbMetricDesc         *prometheus.Desc
+	scrapeDurationSecondsDesc *prometheus.Desc
+	scrapeTruncatedDesc       *prometheus.Desc
+	scrapeErrorsDesc          *prometheus.Desc
+	bytesReadDesc             *prometheus.Desc
+	filesScannedDesc          *prometheus.Desc
 
 	logger slog.Logger
 }
 
-func createFilesCollector(logger slog.Logger, hasTree bool) *filesCollector {
+func createFilesCollector(logger slog.Logger, hasTree bool, labelNames []string, pathLabelNames []string) *filesCollector {
 	c := filesCollector{}
 	c.trees = make(map[string]*treeCollector)
 	c.common = []string{}
@@ -113,14 +498,25 @@ func createFilesCollector(logger slog.Logger, hasTree bool) *filesCollector {
 	if hasTree {
 		c.common = append(c.common, "tree")
 	}
+	c.treeLabels = slices.Clone(c.common)
+	c.common = append(c.common, labelNames...)
+	c.fileCommon = slices.Concat(c.common, pathLabelNames)
 
 	patternLabels := slices.Concat([]string{"pattern"}, c.common)
 	c.fileMatchingGlobNbDesc = optsToDesc(&fileMatchingGlobNbOpts, patternLabels)
 
-	pathLabels := slices.Concat([]string{"path"}, c.common)
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
 	c.fileSizeBytesDesc = optsToDesc(&fileSizeBytesOpts, pathLabels)
 	c.fileModifTimeSecondsDesc = optsToDesc(&fileModifTimeSecondsOpts, pathLabels)
 
+	c.scrapeStats = newScrapeStats()
+	c.changeStates = newChangeStates()
+	c.scrapeDurationSecondsDesc = optsToDesc(&scrapeDurationSecondsOpts, c.treeLabels)
+	c.scrapeTruncatedDesc = optsToDesc(&scrapeTruncatedOpts, c.treeLabels)
+	c.scrapeErrorsDesc = optsToDesc(&scrapeErrorsOpts, slices.Concat(c.treeLabels, []string{"stage"}))
+	c.bytesReadDesc = optsToDesc(&bytesReadOpts, c.treeLabels)
+	c.filesScannedDesc = optsToDesc(&filesScannedOpts, c.treeLabels)
+
 	return &c
 }
 
@@ -132,18 +528,38 @@ func (c *filesCollector) addFileStatCollector(treeName *string, col fileStatColl
 	}
 	tree, found := c.trees[name]
 	if !found {
-		tree = &treeCollector{}
+		tree = &treeCollector{name: name}
 		c.trees[name] = tree
 	}
 	tree.collectors = append(tree.collectors, col)
 }
 
+// initialize number of files or trees collected in parallel
+func (c *filesCollector) useConcurrency(concurrency int) {
+	c.workers = newWorkerPool(concurrency)
+}
+
+// initialize cache of content metrics
+func (c *filesCollector) useContentCache(cache *contentCache) {
+	c.contentCache = cache
+}
+
+// use counters of collections of trees kept across reloads
+func (c *filesCollector) useScrapeStats(stats *scrapeStats) {
+	c.scrapeStats = stats
+}
+
+// use states of files kept across reloads
+func (c *filesCollector) useChangeStates(states *changeStates) {
+	c.changeStates = states
+}
+
 // initialize usage of crc32 hash metric
 func (c *filesCollector) useFileCRC32Metric() {
 	if c.fileCRC32HashDesc != nil {
 		return
 	}
-	pathLabels := slices.Concat([]string{"path"}, c.common)
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
 	c.fileCRC32HashDesc = optsToDesc(&fileCRC32HashOpts, pathLabels)
 
 }
@@ -153,198 +569,1427 @@ func (c *filesCollector) useLineNbMetric() {
 	if c.lineNbMetricDesc != nil {
 		return
 	}
-	pathLabels := slices.Concat([]string{"path"}, c.common)
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
 	c.lineNbMetricDesc = optsToDesc(&lineNbMetricOpts, pathLabels)
 }
 
-// Describe implements the prometheus.Collector interface.
-func (c *filesCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.fileMatchingGlobNbDesc
-	ch <- c.fileSizeBytesDesc
-	ch <- c.fileModifTimeSecondsDesc
-	if c.fileCRC32HashDesc != nil {
-		ch <- c.fileCRC32HashDesc
-	}
-	if c.lineNbMetricDesc != nil {
-		ch <- c.lineNbMetricDesc
+// initialize usage of access time metric
+func (c *filesCollector) useAccessTimeMetric() {
+	if c.fileAccessTimeSecondsDesc != nil {
+		return
 	}
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileAccessTimeSecondsDesc = optsToDesc(&fileAccessTimeSecondsOpts, pathLabels)
 }
 
-// Collect implements the prometheus.Collector interface.
-func (c *filesCollector) Collect(ch chan<- prometheus.Metric) {
-	templater := template.New("pattern").Funcs(
-		template.FuncMap{
-			"now":      time.Now,
-			"sub":      func(a, b int) int { return a - b },
-			"add":      func(a, b int) int { return a + b },
-			"subMonth": func(a time.Month, b int) int { return int(a) - b },
-			"addMonth": func(a time.Month, b int) int { return int(a) + b },
-			"strfTime": func(t time.Time, fmt string) string { return strftime.Format(fmt, t) },
-		})
-	for _, tree := range c.trees {
-		c.CollectTree(ch, templater, tree)
+// initialize usage of change time metric
+func (c *filesCollector) useChangeTimeMetric() {
+	if c.fileChangeTimeSecondsDesc != nil {
+		return
 	}
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileChangeTimeSecondsDesc = optsToDesc(&fileChangeTimeSecondsOpts, pathLabels)
 }
 
-// apply template on path
-func apply(templater *template.Template, pattern string) (string, error) {
-	p, err := templater.Parse(pattern)
-	if err != nil {
-		return pattern, err
+// initialize usage of birth time metric
+func (c *filesCollector) useBirthTimeMetric() {
+	if c.fileBirthTimeSecondsDesc != nil {
+		return
 	}
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileBirthTimeSecondsDesc = optsToDesc(&fileBirthTimeSecondsOpts, pathLabels)
+}
 
-	var pout bytes.Buffer
-	if err = p.Execute(&pout, nil); err != nil {
-		return pattern, err
+// initialize usage of hard link number metric
+func (c *filesCollector) useNlinkMetric() {
+	if c.fileNlinkDesc != nil {
+		return
 	}
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileNlinkDesc = optsToDesc(&fileNlinkOpts, pathLabels)
+}
 
-	return pout.String(), nil
+// initialize usage of allocated bytes metric
+func (c *filesCollector) useAllocatedBytesMetric() {
+	if c.fileAllocatedBytesDesc != nil {
+		return
+	}
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileAllocatedBytesDesc = optsToDesc(&fileAllocatedBytesOpts, pathLabels)
 }
 
-// CollectTree implements the prometheus.Collector interface per tree.
-func (c *filesCollector) CollectTree(ch chan<- prometheus.Metric, templater *template.Template, tree *treeCollector) {
-	patternSet := make(map[string]struct{})
-	fileSet := make(map[string]bool)
-	for _, collector := range tree.collectors {
-		treeRoot, err := apply(templater, collector.treeRoot)
-		if err != nil {
-			c.logger.Warn("Error applying template on tree root", "tree_root", treeRoot, "reason", err)
-			continue
-		}
-		if len(treeRoot) != 0 {
-			if _, err := os.Stat(treeRoot); os.IsNotExist(err) {
-				c.logger.Debug("Skip collecting file stats because tree root not found", "tree_root", treeRoot)
-				continue
-			}
-		}
-		for _, pattern := range collector.filesPatterns {
-			// expanded pattern
-			realPattern, err := apply(templater, pattern)
-			if err != nil {
-				c.logger.Warn("Error applying template on file pattern", "pattern", pattern, "reason", err)
-				continue
-			}
+// initialize usage of stat info metric
+func (c *filesCollector) useStatInfoMetric() {
+	if c.fileStatInfoDesc != nil {
+		return
+	}
+	infoLabels := slices.Concat([]string{"path"}, c.fileCommon, []string{"mode", "uid", "gid", "user", "group", "type"})
+	c.fileStatInfoDesc = optsToDesc(&fileStatInfoOpts, infoLabels)
+	c.ownerNames = newOwnerNames()
+}
 
-			// only collect pattern once
-			fullPattern := path.Join(treeRoot, realPattern)
-			if _, ok := patternSet[fullPattern]; ok {
-				continue
-			}
-			patternSet[fullPattern] = struct{}{}
+// initialize usage of hash info metric
+func (c *filesCollector) useHashInfoMetric() {
+	if c.fileHashInfoDesc != nil {
+		return
+	}
+	hashLabels := slices.Concat([]string{"path"}, c.fileCommon, []string{"algorithm", "digest"})
+	c.fileHashInfoDesc = optsToDesc(&fileHashInfoOpts, hashLabels)
+}
 
-			// get files matching pattern
-			matchingFileNb := 0
-			basepath, patternPart := doublestar.SplitPattern(realPattern)
+// initialize usage of truncated hash metric
+func (c *filesCollector) useHashTruncatedMetric() {
+	if c.fileHashTruncatedDesc != nil {
+		return
+	}
+	hashLabels := slices.Concat([]string{"path"}, c.fileCommon, []string{"algorithm"})
+	c.fileHashTruncatedDesc = optsToDesc(&fileHashTruncatedOpts, hashLabels)
+}
 
-			// apply treeRoot
-			patternRoot := path.Join(treeRoot, basepath)
-			fsys := os.DirFS(patternRoot)
-			if matches, err := doublestar.Glob(fsys, patternPart); err == nil {
-				for _, relFilePath := range matches {
-					realFilePath := path.Join(patternRoot, relFilePath)
-					filePath := path.Join(basepath, relFilePath)
-					// only collect files once
-					if isProcessable, ok := fileSet[realFilePath]; ok {
-						if isProcessable {
-							matchingFileNb++
-						}
-						continue
-					}
-
-					isFileProcessed := c.collectFileMetrics(ch, filePath, realFilePath, &matchingFileNb, collector.labels)
-					fileSet[realFilePath] = isFileProcessed
-					if isFileProcessed {
-						if collector.enableCRC32Metric || collector.enableLineNbMetric {
-							c.collectContentMetrics(ch, filePath, realFilePath,
-								collector.enableCRC32Metric,
-								collector.enableLineNbMetric,
-								collector.labels)
-						}
-					}
-				}
-			} else {
-				c.logger.Debug("Error getting matches for glob", "pattern", pattern, "reason", err)
-			}
-			ch <- prometheus.MustNewConstMetric(c.fileMatchingGlobNbDesc, prometheus.GaugeValue,
-				float64(matchingFileNb),
-				slices.Concat([]string{pattern}, collector.labels)...)
-		}
+// initialize usage of line matchers metric
+func (c *filesCollector) useMatchLinesMetric() {
+	if c.fileMatchLinesDesc != nil {
+		return
 	}
+	matcherLabels := slices.Concat([]string{"path"}, c.fileCommon, []string{"matcher"})
+	c.fileMatchLinesDesc = optsToDesc(&fileMatchLinesOpts, matcherLabels)
 }
 
-// Collect metrics for a file and feed
-func (c *filesCollector) collectFileMetrics(ch chan<- prometheus.Metric, filePath string, realFilePath string, nbFile *int, labels []string) bool {
-	// Metrics based on Fileinfo
-	if fileinfo, err := os.Stat(realFilePath); err == nil {
-		if fileinfo.IsDir() {
-			return false
-		}
-		*nbFile++
-		metricLabels := slices.Concat([]string{filePath}, labels)
-		ch <- prometheus.MustNewConstMetric(c.fileSizeBytesDesc, prometheus.GaugeValue,
-			float64(fileinfo.Size()),
-			metricLabels...)
-		modTime := fileinfo.ModTime()
-		ch <- prometheus.MustNewConstMetric(c.fileModifTimeSecondsDesc, prometheus.GaugeValue,
-			float64(modTime.Unix())+float64(modTime.Nanosecond())/1000000000.0,
-			metricLabels...)
-	} else {
-		c.logger.Debug("Error getting file info", "path", realFilePath, "reason", err)
-		return false
+// initialize usage of last matching line metric
+func (c *filesCollector) useLastMatchLineMetric() {
+	if c.fileLastMatchLineDesc != nil {
+		return
 	}
-	return true
+	matcherLabels := slices.Concat([]string{"path"}, c.fileCommon, []string{"matcher"})
+	c.fileLastMatchLineDesc = optsToDesc(&fileLastMatchLineOpts, matcherLabels)
 }
 
-// Collect metrics for a file content
-func (c *filesCollector) collectContentMetrics(ch chan<- prometheus.Metric, filePath string, realFilePath string,
-	enableCRC32 bool, enableLineNb bool, labels []string) {
-	file, err := os.Open(realFilePath)
-	if err != nil {
-		c.logger.Debug("Error getting content file hash while opening", "path", realFilePath, "reason", err)
+// initialize usage of metric extracted or selected from file content
+func (c *filesCollector) useValueMetric(name string, help string, labelNames []string) {
+	if _, found := c.valueDescs[name]; found {
 		return
 	}
-	defer file.Close()
+	if c.valueDescs == nil {
+		c.valueDescs = make(map[string]*prometheus.Desc)
+	}
+	valueLabels := slices.Concat([]string{"path"}, c.fileCommon, labelNames)
+	c.valueDescs[name] = prometheus.NewDesc(name, help, valueLabels, nil)
+}
 
-	hash := crc32.NewIEEE()
-	lineNb := 0
+// initialize usage of parse error and text value of documents
+func (c *filesCollector) useDocumentMetrics() {
+	if c.fileParseErrorDesc != nil {
+		return
+	}
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileParseErrorDesc = optsToDesc(&fileParseErrorOpts, pathLabels)
+	selectorLabels := slices.Concat([]string{"path"}, c.fileCommon, []string{"selector", "value"})
+	c.fileSelectorInfoDesc = optsToDesc(&fileSelectorInfoOpts, selectorLabels)
+}
 
-	// read chunks of 32k
-	buf := make([]byte, 32*1024)
-	lineSep := []byte{'\n'}
+// initialize usage of directory metrics
+func (c *filesCollector) useDirMetrics() {
+	if c.fileDirEntriesDesc != nil {
+		return
+	}
+	// only directories have these metrics - type label is always dir
+	dirLabels := slices.Concat([]string{"path"}, c.fileCommon, []string{"type"})
+	c.fileDirEntriesDesc = optsToDesc(&fileDirEntriesOpts, dirLabels)
+	c.fileDirSizeBytesDesc = optsToDesc(&fileDirSizeBytesOpts, dirLabels)
+}
 
-ReadFile:
-	for {
-		b, err := file.Read(buf)
-		slice := buf[:b]
-		if enableLineNb {
-			lineNb += bytes.Count(slice, lineSep)
-		}
-		if enableCRC32 {
-			if _, errHash := hash.Write(slice); errHash != nil {
-				c.logger.Debug("Error generating CRC32 hash of file", "path", realFilePath, "reason", errHash)
-				enableCRC32 = false
-			}
-		}
+// initialize usage of limit of number of files matching pattern
+func (c *filesCollector) useGlobLimitMetric() {
+	if c.fileGlobLimitReachedDesc != nil {
+		return
+	}
+	c.fileGlobLimitReachedDesc = optsToDesc(&fileGlobLimitReachedOpts, slices.Concat([]string{"pattern"}, c.common))
+}
 
-		switch {
-		case err == io.EOF:
-			break ReadFile
+// initialize usage of metrics of changes of files
+func (c *filesCollector) useChangeMetrics() {
+	if c.fileModificationsDesc != nil {
+		return
+	}
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileModificationsDesc = optsToDesc(&fileModificationsOpts, pathLabels)
+	c.fileFirstSeenDesc = optsToDesc(&fileFirstSeenOpts, pathLabels)
+	c.fileContentChangesDesc = optsToDesc(&fileContentChangesOpts, pathLabels)
+	c.fileTruncationsDesc = optsToDesc(&fileTruncationsOpts, pathLabels)
+	c.fileReplacementsDesc = optsToDesc(&fileReplacementsOpts, pathLabels)
+}
 
-		case err != nil:
-			c.logger.Debug("Error reading content of file", "path", realFilePath, "reason", err)
+// initialize usage of type of content metric
+func (c *filesCollector) useContentTypeMetric() {
+	if c.fileContentTypeInfoDesc != nil {
+		return
+	}
+	c.fileContentTypeInfoDesc = optsToDesc(&fileContentTypeInfoOpts, slices.Concat([]string{"path"}, c.fileCommon, []string{"mime"}))
+}
+
+// initialize usage of metrics of decompressed content
+func (c *filesCollector) useDecompressMetrics() {
+	if c.fileUncompressedBytesDesc != nil {
+		return
+	}
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileUncompressedBytesDesc = optsToDesc(&fileUncompressedBytesOpts, pathLabels)
+	c.fileIntegrityErrorDesc = optsToDesc(&fileIntegrityErrorOpts, pathLabels)
+}
+
+// initialize usage of metric of files whose content is skipped
+func (c *filesCollector) useContentSkippedMetric() {
+	if c.fileContentSkippedDesc != nil {
+		return
+	}
+	c.fileContentSkippedDesc = optsToDesc(&fileContentSkippedOpts, slices.Concat([]string{"path"}, c.fileCommon, []string{"reason"}))
+}
+
+// initialize usage of metrics of expected files
+func (c *filesCollector) useExpectedMetrics() {
+	if c.fileExistsDesc != nil {
+		return
+	}
+	c.fileExistsDesc = optsToDesc(&fileExistsOpts, slices.Concat([]string{"path"}, c.common))
+	c.fileExpectedMissingDesc = optsToDesc(&fileExpectedMissingOpts, slices.Concat([]string{"pattern"}, c.common))
+}
+
+// initialize usage of filesystem metrics of trees
+func (c *filesCollector) useFilesystemMetrics() {
+	if c.filesystemSizeBytesDesc != nil {
+		return
+	}
+	filesystemLabels := slices.Concat(c.treeLabels, []string{"mountpoint"})
+	c.filesystemSizeBytesDesc = optsToDesc(&filesystemSizeBytesOpts, filesystemLabels)
+	c.filesystemAvailBytesDesc = optsToDesc(&filesystemAvailBytesOpts, filesystemLabels)
+	c.filesystemFilesFreeDesc = optsToDesc(&filesystemFilesFreeOpts, filesystemLabels)
+}
+
+// initialize usage of symbolic link metrics
+func (c *filesCollector) useSymlinkMetrics() {
+	if c.fileSymlinkInfoDesc != nil {
+		return
+	}
+	symlinkLabels := slices.Concat([]string{"path"}, c.fileCommon, []string{"target"})
+	c.fileSymlinkInfoDesc = optsToDesc(&fileSymlinkInfoOpts, symlinkLabels)
+	pathLabels := slices.Concat([]string{"path"}, c.fileCommon)
+	c.fileSymlinkBrokenDesc = optsToDesc(&fileSymlinkBrokenOpts, pathLabels)
+}
+
+// initialize usage of per pattern aggregate metrics
+func (c *filesCollector) useAggregateMetrics() {
+	if c.fileGlobSizeBytesDesc != nil {
+		return
+	}
+	patternLabels := slices.Concat([]string{"pattern"}, c.common)
+	c.fileGlobSizeBytesDesc = optsToDesc(&fileGlobSizeBytesOpts, patternLabels)
+	c.fileGlobLargestSizeBytesDesc = optsToDesc(&fileGlobLargestSizeBytesOpts, patternLabels)
+	c.fileGlobModifTimeMinSecondsDesc = optsToDesc(&fileGlobModifTimeMinSecondsOpts, patternLabels)
+	c.fileGlobModifTimeMaxSecondsDesc = optsToDesc(&fileGlobModifTimeMaxSecondsOpts, patternLabels)
+	c.fileGlobModifTimeSumSecondsDesc = optsToDesc(&fileGlobModifTimeSumSecondsOpts, patternLabels)
+	c.fileGlobOldestAgeSecondsDesc = optsToDesc(&fileGlobOldestAgeSecondsOpts, patternLabels)
+	c.fileGlobNewestAgeSecondsDesc = optsToDesc(&fileGlobNewestAgeSecondsOpts, patternLabels)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *filesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.fileMatchingGlobNbDesc
+	ch <- c.fileSizeBytesDesc
+	ch <- c.fileModifTimeSecondsDesc
+	ch <- c.scrapeDurationSecondsDesc
+	ch <- c.scrapeTruncatedDesc
+	ch <- c.scrapeErrorsDesc
+	ch <- c.bytesReadDesc
+	ch <- c.filesScannedDesc
+	if c.fileCRC32HashDesc != nil {
+		ch <- c.fileCRC32HashDesc
+	}
+	if c.lineNbMetricDesc != nil {
+		ch <- c.lineNbMetricDesc
+	}
+	if c.fileAccessTimeSecondsDesc != nil {
+		ch <- c.fileAccessTimeSecondsDesc
+	}
+	if c.fileChangeTimeSecondsDesc != nil {
+		ch <- c.fileChangeTimeSecondsDesc
+	}
+	if c.fileBirthTimeSecondsDesc != nil {
+		ch <- c.fileBirthTimeSecondsDesc
+	}
+	if c.fileNlinkDesc != nil {
+		ch <- c.fileNlinkDesc
+	}
+	if c.fileAllocatedBytesDesc != nil {
+		ch <- c.fileAllocatedBytesDesc
+	}
+	if c.fileStatInfoDesc != nil {
+		ch <- c.fileStatInfoDesc
+	}
+	if c.fileHashInfoDesc != nil {
+		ch <- c.fileHashInfoDesc
+	}
+	if c.fileHashTruncatedDesc != nil {
+		ch <- c.fileHashTruncatedDesc
+	}
+	if c.fileMatchLinesDesc != nil {
+		ch <- c.fileMatchLinesDesc
+	}
+	if c.fileLastMatchLineDesc != nil {
+		ch <- c.fileLastMatchLineDesc
+	}
+	if c.fileDirEntriesDesc != nil {
+		ch <- c.fileDirEntriesDesc
+		ch <- c.fileDirSizeBytesDesc
+	}
+	if c.fileGlobLimitReachedDesc != nil {
+		ch <- c.fileGlobLimitReachedDesc
+	}
+	if c.fileContentSkippedDesc != nil {
+		ch <- c.fileContentSkippedDesc
+	}
+	if c.fileContentTypeInfoDesc != nil {
+		ch <- c.fileContentTypeInfoDesc
+	}
+	if c.fileModificationsDesc != nil {
+		ch <- c.fileModificationsDesc
+		ch <- c.fileFirstSeenDesc
+		ch <- c.fileContentChangesDesc
+		ch <- c.fileTruncationsDesc
+		ch <- c.fileReplacementsDesc
+	}
+	if c.fileUncompressedBytesDesc != nil {
+		ch <- c.fileUncompressedBytesDesc
+		ch <- c.fileIntegrityErrorDesc
+	}
+	if c.fileExistsDesc != nil {
+		ch <- c.fileExistsDesc
+		ch <- c.fileExpectedMissingDesc
+	}
+	if c.filesystemSizeBytesDesc != nil {
+		ch <- c.filesystemSizeBytesDesc
+		ch <- c.filesystemAvailBytesDesc
+		ch <- c.filesystemFilesFreeDesc
+	}
+	if c.fileSymlinkInfoDesc != nil {
+		ch <- c.fileSymlinkInfoDesc
+		ch <- c.fileSymlinkBrokenDesc
+	}
+	if c.fileParseErrorDesc != nil {
+		ch <- c.fileParseErrorDesc
+		ch <- c.fileSelectorInfoDesc
+	}
+	for _, desc := range c.valueDescs {
+		ch <- desc
+	}
+	if c.fileGlobSizeBytesDesc != nil {
+		ch <- c.fileGlobSizeBytesDesc
+		ch <- c.fileGlobLargestSizeBytesDesc
+		ch <- c.fileGlobModifTimeMinSecondsDesc
+		ch <- c.fileGlobModifTimeMaxSecondsDesc
+		ch <- c.fileGlobModifTimeSumSecondsDesc
+		ch <- c.fileGlobOldestAgeSecondsDesc
+		ch <- c.fileGlobNewestAgeSecondsDesc
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *filesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext collects metrics of trees until context is done.
+func (c *filesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	if c.ownerNames != nil {
+		c.ownerNames.refresh()
+	}
+
+	// templater is not shared because parsing modifies it
+	trees := slices.Collect(maps.Values(c.trees))
+	c.workers.spawnEach(len(trees), func(i int) {
+		c.CollectTree(ctx, ch, newTemplater(), trees[i])
+	})
+}
+
+// create templater used for expanding patterns
+func newTemplater() *template.Template {
+	return template.New("pattern").Funcs(
+		template.FuncMap{
+			"now":      time.Now,
+			"sub":      func(a, b int) int { return a - b },
+			"add":      func(a, b int) int { return a + b },
+			"subMonth": func(a time.Month, b int) int { return int(a) - b },
+			"addMonth": func(a time.Month, b int) int { return int(a) + b },
+			"strfTime": func(t time.Time, fmt string) string { return strftime.Format(fmt, t) },
+		})
+}
+
+// apply template on path
+func apply(templater *template.Template, pattern string) (string, error) {
+	// parsing a text without action doesn't replace previous template
+	if !strings.Contains(pattern, "{{") {
+		return pattern, nil
+	}
+	p, err := templater.Parse(pattern)
+	if err != nil {
+		return pattern, err
+	}
+
+	var pout bytes.Buffer
+	if err = p.Execute(&pout, nil); err != nil {
+		return pattern, err
+	}
+
+	return pout.String(), nil
+}
+
+// CollectTree implements the prometheus.Collector interface per tree.
+//
+// Globs and files are skipped once the context is done and the tree is reported as truncated.
+func (c *filesCollector) CollectTree(ctx context.Context, ch chan<- prometheus.Metric, templater *template.Template, tree *treeCollector) {
+	start := time.Now()
+	stats := c.scrapeStats.tree(tree.name)
+	truncated := false
+	defer func() {
+		c.collectScrapeMetrics(ch, tree, stats, time.Since(start), truncated)
+	}()
+
+	// expand patterns - only collect pattern once
+	patternSet := make(map[string]struct{})
+	globs := []*patternGlob{}
+	// files of patterns which could not be expanded are unknown
+	patternsFailed := false
+	excludes := make(map[*fileStatCollector][]string)
+	expectedSet := make(map[string]struct{})
+	expected := []*expectedFile{}
+	filesystemDirs := []string{}
+	for i := range tree.collectors {
+		collector := &tree.collectors[i]
+		treeRoot := collector.treeRoot
+		if !collector.literalTreeRoot {
+			var err error
+			if treeRoot, err = apply(templater, collector.treeRoot); err != nil {
+				c.logger.Warn("Error applying template on tree root", "tree_root", treeRoot, "reason", err)
+				stats.addError(scrapeStageTemplate)
+				patternsFailed = true
+				continue
+			}
+		}
+		if len(treeRoot) != 0 {
+			if _, err := os.Stat(treeRoot); os.IsNotExist(err) {
+				c.logger.Debug("Skip collecting file stats because tree root not found", "tree_root", treeRoot)
+				continue
+			}
+		}
+		if collector.enableFilesystemMetric {
+			filesystemDirs = append(filesystemDirs, cmp.Or(treeRoot, "."))
+		}
+		for _, pattern := range collector.excludePatterns {
+			realPattern, err := apply(templater, pattern)
+			if err != nil {
+				c.logger.Warn("Error applying template on exclude pattern", "pattern", pattern, "reason", err)
+				stats.addError(scrapeStageTemplate)
+				continue
+			}
+			if !doublestar.ValidatePattern(realPattern) {
+				c.logger.Warn("Invalid exclude pattern", "pattern", pattern, "expanded_pattern", realPattern)
+				stats.addError(scrapeStageTemplate)
+				continue
+			}
+			excludes[collector] = append(excludes[collector], realPattern)
+		}
+		for _, expectedPath := range collector.expectedPaths {
+			realPath, err := apply(templater, expectedPath)
+			if err != nil {
+				c.logger.Warn("Error applying template on expected path", "path", expectedPath, "reason", err)
+				stats.addError(scrapeStageTemplate)
+				continue
+			}
+
+			// only check expected file once
+			realFilePath := path.Join(treeRoot, realPath)
+			if _, ok := expectedSet[realFilePath]; ok {
+				continue
+			}
+			expectedSet[realFilePath] = struct{}{}
+			expected = append(expected, &expectedFile{
+				collector:    collector,
+				filePath:     path.Clean(realPath),
+				realFilePath: realFilePath,
+			})
+		}
+		for _, pattern := range collector.filesPatterns {
+			// expanded pattern
+			realPattern, err := apply(templater, pattern)
+			if err != nil {
+				c.logger.Warn("Error applying template on file pattern", "pattern", pattern, "reason", err)
+				stats.addError(scrapeStageTemplate)
+				patternsFailed = true
+				continue
+			}
+
+			// only collect pattern once
+			fullPattern := path.Join(treeRoot, realPattern)
+			if _, ok := patternSet[fullPattern]; ok {
+				continue
+			}
+			patternSet[fullPattern] = struct{}{}
+
+			// apply treeRoot
+			basepath, patternPart := doublestar.SplitPattern(realPattern)
+			globs = append(globs, &patternGlob{
+				collector:   collector,
+				pattern:     pattern,
+				basepath:    basepath,
+				patternRoot: path.Join(treeRoot, basepath),
+				patternPart: patternPart,
+			})
+			if collector.enableFilesystemMetric {
+				filesystemDirs = append(filesystemDirs, path.Join(treeRoot, basepath))
+			}
+		}
+	}
+
+	// get files matching patterns
+	c.workers.forEach(len(globs), func(i int) {
+		glob := globs[i]
+		if ctx.Err() != nil {
 			return
 		}
+		fsys := newLoopSafeFS(ctx, glob.patternRoot, func(name string) {
+			c.logger.Debug("Skip directory looping on parent directory", "pattern", glob.pattern, "directory", path.Join(glob.basepath, name))
+		})
+		fsys.maxDepth = glob.collector.maxDepth
+		options := []doublestar.GlobOption{}
+		if glob.collector.symlinks != symlinksFollow {
+			options = append(options, doublestar.WithNoFollow())
+		}
+		if !glob.collector.includeDirectories {
+			options = append(options, doublestar.WithFilesOnly())
+		}
+		// maximum number of files only counts files which are not excluded
+		maxFiles := glob.collector.maxFiles
+		err := doublestar.GlobWalk(fsys, glob.patternPart, func(match string, _ fs.DirEntry) error {
+			if isExcluded(excludes[glob.collector], path.Join(glob.basepath, match)) {
+				return nil
+			}
+			if maxFiles != 0 && len(glob.matches) == maxFiles {
+				glob.limitReached = true
+				return errGlobLimitReached
+			}
+			glob.matches = append(glob.matches, match)
+			return nil
+		}, options...)
+		if glob.limitReached {
+			c.logger.Debug("Stop matching pattern at maximum number of files", "pattern", glob.pattern, "max_files", maxFiles)
+		} else if err != nil {
+			c.logger.Debug("Error getting matches for glob", "pattern", glob.pattern, "reason", err)
+			if ctx.Err() == nil {
+				stats.addError(scrapeStageGlob)
+			}
+			glob.matches = nil
+			glob.failed = true
+		}
+	})
+
+	// only collect files once with config of first matching pattern
+	dirSizes := newDirSizeCache()
+	fileSet := make(map[string]int)
+	files := []*treeFile{}
+	for _, glob := range globs {
+		glob.files = make([]int, 0, len(glob.matches))
+		for _, relFilePath := range glob.matches {
+			filePath := path.Join(glob.basepath, relFilePath)
+			pathLabels, matched := glob.collector.pathLabels(filePath)
+			if !matched && glob.collector.dropPathLabelsMismatch {
+				c.logger.Debug("Drop file not matching path labels", "path", filePath, "pattern", glob.pattern)
+				continue
+			}
+			realFilePath := path.Join(glob.patternRoot, relFilePath)
+			index, found := fileSet[realFilePath]
+			if !found {
+				index = len(files)
+				fileSet[realFilePath] = index
+				files = append(files, &treeFile{
+					collector:    glob.collector,
+					filePath:     filePath,
+					realFilePath: realFilePath,
+					labels:       slices.Concat(glob.collector.labels, pathLabels),
+					stats:        stats,
+					dirSizes:     dirSizes,
+				})
+			}
+			glob.files = append(glob.files, index)
+		}
 	}
 
-	metricLabels := slices.Concat([]string{filePath}, labels)
-	if enableCRC32 {
+	// collect metrics of files
+	c.workers.forEach(len(files), func(i int) {
+		file := files[i]
+		if ctx.Err() != nil {
+			return
+		}
+		collector := file.collector
+		fileinfo := c.collectFileMetrics(ctx, ch, file)
+		file.isProcessed = fileinfo != nil
+		file.fileinfo = fileinfo
+		if file.isProcessed && !collector.aggregateOnly && !fileinfo.IsDir() {
+			if collector.hasContentMetric() {
+				c.collectContentMetrics(ctx, ch, file, fileinfo)
+			}
+		}
+		if file.isProcessed && !collector.aggregateOnly && collector.enableChangeMetrics {
+			c.collectChangeMetrics(ch, tree, file)
+		}
+	})
+	// states of files which may be missing from matches are kept
+	globsIncomplete := slices.ContainsFunc(globs, func(glob *patternGlob) bool {
+		return glob.failed || glob.limitReached
+	})
+	if ctx.Err() == nil && !patternsFailed && !globsIncomplete {
+		c.pruneChangeStates(tree, files)
+	}
+
+	// check existence of expected files
+	c.workers.forEach(len(expected), func(i int) {
+		if ctx.Err() != nil {
+			return
+		}
+		_, err := os.Stat(expected[i].realFilePath)
+		expected[i].checked = true
+		expected[i].exists = err == nil
+		if err != nil && !os.IsNotExist(err) {
+			c.logger.Debug("Error getting info of expected file", "path", expected[i].realFilePath, "reason", err)
+			stats.addError(scrapeStageStat)
+		}
+	})
+	c.collectExpectedMetrics(ch, expected)
+	truncated = ctx.Err() != nil
+
+	c.collectFilesystemMetrics(ch, tree, stats, filesystemDirs)
+
+	// count processed files matching patterns
+	now := time.Now()
+	for _, glob := range globs {
+		matchingFileNb := 0
+		aggregate := globAggregate{}
+		for _, index := range glob.files {
+			if files[index].isProcessed {
+				matchingFileNb++
+				if glob.collector.aggregateOnly && !files[index].fileinfo.IsDir() {
+					aggregate.add(files[index].fileinfo)
+				}
+			}
+		}
+		patternLabels := slices.Concat([]string{glob.pattern}, glob.collector.labels)
+		ch <- prometheus.MustNewConstMetric(c.fileMatchingGlobNbDesc, prometheus.GaugeValue,
+			float64(matchingFileNb),
+			patternLabels...)
+		if glob.collector.aggregateOnly {
+			c.collectAggregateMetrics(ch, &aggregate, now, patternLabels)
+		}
+		if glob.collector.maxFiles != 0 {
+			limitReached := 0.0
+			if glob.limitReached {
+				limitReached = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.fileGlobLimitReachedDesc, prometheus.GaugeValue,
+				limitReached,
+				patternLabels...)
+		}
+	}
+}
+
+// collect duration of collection of tree and counters of all its collections
+func (c *filesCollector) collectScrapeMetrics(ch chan<- prometheus.Metric, tree *treeCollector, stats *treeScrapeStats, duration time.Duration, truncated bool) {
+	treeLabels := []string{}
+	if len(c.treeLabels) != 0 {
+		treeLabels = append(treeLabels, tree.name)
+	}
+	ch <- prometheus.MustNewConstMetric(c.scrapeDurationSecondsDesc, prometheus.GaugeValue,
+		duration.Seconds(),
+		treeLabels...)
+	scrapeTruncated := 0.0
+	if truncated {
+		scrapeTruncated = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.scrapeTruncatedDesc, prometheus.GaugeValue,
+		scrapeTruncated,
+		treeLabels...)
+	for _, stage := range scrapeStages {
+		ch <- prometheus.MustNewConstMetric(c.scrapeErrorsDesc, prometheus.CounterValue,
+			float64(stats.errors[stage].Load()),
+			slices.Concat(treeLabels, []string{stage})...)
+	}
+	ch <- prometheus.MustNewConstMetric(c.bytesReadDesc, prometheus.CounterValue,
+		float64(stats.bytesRead.Load()),
+		treeLabels...)
+	ch <- prometheus.MustNewConstMetric(c.filesScannedDesc, prometheus.CounterValue,
+		float64(stats.filesScanned.Load()),
+		treeLabels...)
+}
+
+// collect counters of changes of file since it was first seen
+func (c *filesCollector) collectChangeMetrics(ch chan<- prometheus.Metric, tree *treeCollector, file *treeFile) {
+	state := c.changeStates.update(changeKey{tree: tree.name, path: file.filePath}, fileObservation{
+		fileinfo:  file.fileinfo,
+		hasDigest: file.hasDigest,
+		digest:    file.digest,
+	})
+	metricLabels := slices.Concat([]string{file.filePath}, file.labels)
+	ch <- prometheus.MustNewConstMetric(c.fileFirstSeenDesc, prometheus.GaugeValue,
+		timeToSeconds(time.Unix(0, state.firstSeenNano)),
+		metricLabels...)
+	ch <- prometheus.MustNewConstMetric(c.fileModificationsDesc, prometheus.CounterValue,
+		float64(state.modifications),
+		metricLabels...)
+	ch <- prometheus.MustNewConstMetric(c.fileTruncationsDesc, prometheus.CounterValue,
+		float64(state.truncations),
+		metricLabels...)
+	ch <- prometheus.MustNewConstMetric(c.fileReplacementsDesc, prometheus.CounterValue,
+		float64(state.replacements),
+		metricLabels...)
+	if state.hasDigest {
+		ch <- prometheus.MustNewConstMetric(c.fileContentChangesDesc, prometheus.CounterValue,
+			float64(state.contentChanges),
+			metricLabels...)
+	}
+}
+
+// forget states of files of tree which disappeared
+func (c *filesCollector) pruneChangeStates(tree *treeCollector, files []*treeFile) {
+	seen := make(map[string]struct{})
+	for _, file := range files {
+		if file.isProcessed && file.collector.enableChangeMetrics {
+			seen[file.filePath] = struct{}{}
+		}
+	}
+	c.changeStates.prune(tree.name, seen)
+}
+
+// identity of a group of files from its patterns
+func groupPattern(patterns []string) string {
+	return strings.Join(patterns, ",")
+}
+
+// identity of group of files of collector
+func (col *fileStatCollector) groupPattern() string {
+	return groupPattern(col.filesPatterns)
+}
+
+// collect existence of expected files and number of missing ones per group of files - unchecked files are skipped
+func (c *filesCollector) collectExpectedMetrics(ch chan<- prometheus.Metric, expected []*expectedFile) {
+	collectors := []*fileStatCollector{}
+	missing := make(map[*fileStatCollector]int)
+	for _, file := range expected {
+		if !file.checked {
+			continue
+		}
+		if _, found := missing[file.collector]; !found {
+			collectors = append(collectors, file.collector)
+			missing[file.collector] = 0
+		}
+		exists := 0.0
+		if file.exists {
+			exists = 1
+		} else {
+			missing[file.collector]++
+		}
+		ch <- prometheus.MustNewConstMetric(c.fileExistsDesc, prometheus.GaugeValue,
+			exists,
+			slices.Concat([]string{file.filePath}, file.collector.labels)...)
+	}
+	for _, collector := range collectors {
+		ch <- prometheus.MustNewConstMetric(c.fileExpectedMissingDesc, prometheus.GaugeValue,
+			float64(missing[collector]),
+			slices.Concat([]string{collector.groupPattern()}, collector.labels)...)
+	}
+}
+
+// collect capacity of filesystems of tree directories - once per device
+func (c *filesCollector) collectFilesystemMetrics(ch chan<- prometheus.Metric, tree *treeCollector, stats *treeScrapeStats, dirs []string) {
+	if len(dirs) == 0 {
+		return
+	}
+	treeLabels := tree.collectors[0].labels[:len(c.treeLabels)]
+	devices := make(map[string]struct{})
+	for _, dir := range dirs {
+		stat, err := statFilesystem(dir)
+		if err != nil {
+			c.logger.Debug("Error getting filesystem capacity", "path", dir, "reason", err)
+			stats.addError(scrapeStageStat)
+			continue
+		}
+		device := stat.mountPoint
+		if fileinfo, err := os.Stat(dir); err == nil {
+			if id, _, ok := fileIdentity(fileinfo); ok {
+				device = strconv.FormatUint(id, 10)
+			}
+		}
+		if _, found := devices[device]; found {
+			continue
+		}
+		devices[device] = struct{}{}
+
+		filesystemLabels := slices.Concat(treeLabels, []string{stat.mountPoint})
+		ch <- prometheus.MustNewConstMetric(c.filesystemSizeBytesDesc, prometheus.GaugeValue,
+			float64(stat.sizeBytes),
+			filesystemLabels...)
+		ch <- prometheus.MustNewConstMetric(c.filesystemAvailBytesDesc, prometheus.GaugeValue,
+			float64(stat.availBytes),
+			filesystemLabels...)
+		if stat.hasFilesFree {
+			ch <- prometheus.MustNewConstMetric(c.filesystemFilesFreeDesc, prometheus.GaugeValue,
+				float64(stat.filesFree),
+				filesystemLabels...)
+		}
+	}
+}
+
+// values of labels extracted from path of file - false if path doesn't match
+func (col *fileStatCollector) pathLabels(filePath string) ([]string, bool) {
+	values := make([]string, len(col.pathLabelGroups))
+	if col.pathLabelsRegex == nil {
+		return values, true
+	}
+	groups := col.pathLabelsRegex.FindStringSubmatch(filePath)
+	if groups == nil {
+		return values, false
+	}
+	for i, group := range col.pathLabelGroups {
+		if group > 0 {
+			values[i] = groups[group]
+		}
+	}
+	return values, true
+}
+
+// true if path of file relative to tree root matches an exclude pattern
+func isExcluded(excludePatterns []string, filePath string) bool {
+	for _, pattern := range excludePatterns {
+		if doublestar.MatchUnvalidated(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// Aggregated statistics of files matching a pattern
+type globAggregate struct {
+	nbFiles      int
+	sizeBytes    int64
+	largestBytes int64
+	minModTime   time.Time
+	maxModTime   time.Time
+	sumModTime   float64
+}
+
+// add statistics of file
+func (a *globAggregate) add(fileinfo os.FileInfo) {
+	size, modTime := fileinfo.Size(), fileinfo.ModTime()
+	if a.nbFiles == 0 || modTime.Before(a.minModTime) {
+		a.minModTime = modTime
+	}
+	if a.nbFiles == 0 || modTime.After(a.maxModTime) {
+		a.maxModTime = modTime
+	}
+	a.nbFiles++
+	a.sizeBytes += size
+	a.largestBytes = max(a.largestBytes, size)
+	a.sumModTime += timeToSeconds(modTime)
+}
+
+// Collect aggregate metrics of a pattern - time related metrics are undefined without file
+func (c *filesCollector) collectAggregateMetrics(ch chan<- prometheus.Metric, aggregate *globAggregate, now time.Time, patternLabels []string) {
+	ch <- prometheus.MustNewConstMetric(c.fileGlobSizeBytesDesc, prometheus.GaugeValue,
+		float64(aggregate.sizeBytes),
+		patternLabels...)
+	ch <- prometheus.MustNewConstMetric(c.fileGlobLargestSizeBytesDesc, prometheus.GaugeValue,
+		float64(aggregate.largestBytes),
+		patternLabels...)
+	ch <- prometheus.MustNewConstMetric(c.fileGlobModifTimeSumSecondsDesc, prometheus.GaugeValue,
+		aggregate.sumModTime,
+		patternLabels...)
+	if aggregate.nbFiles == 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.fileGlobModifTimeMinSecondsDesc, prometheus.GaugeValue,
+		timeToSeconds(aggregate.minModTime),
+		patternLabels...)
+	ch <- prometheus.MustNewConstMetric(c.fileGlobModifTimeMaxSecondsDesc, prometheus.GaugeValue,
+		timeToSeconds(aggregate.maxModTime),
+		patternLabels...)
+	ch <- prometheus.MustNewConstMetric(c.fileGlobOldestAgeSecondsDesc, prometheus.GaugeValue,
+		now.Sub(aggregate.minModTime).Seconds(),
+		patternLabels...)
+	ch <- prometheus.MustNewConstMetric(c.fileGlobNewestAgeSecondsDesc, prometheus.GaugeValue,
+		now.Sub(aggregate.maxModTime).Seconds(),
+		patternLabels...)
+}
+
+// Collect metrics for a file and feed - returns file info if file is processed
+func (c *filesCollector) collectFileMetrics(ctx context.Context, ch chan<- prometheus.Metric, file *treeFile) os.FileInfo {
+	collector := file.collector
+
+	// Metrics based on Fileinfo
+	file.stats.filesScanned.Add(1)
+	status, err := c.statFile(file)
+	if err != nil {
+		c.logger.Debug("Error getting file info", "path", file.realFilePath, "reason", err)
+		file.stats.addError(scrapeStageStat)
+		return nil
+	}
+	metricLabels := slices.Concat([]string{file.filePath}, file.labels)
+	if status.isSymlink && !collector.aggregateOnly {
+		c.collectSymlinkMetrics(ch, &status, metricLabels)
+	}
+	fileinfo := status.fileinfo
+	if fileinfo == nil || (fileinfo.IsDir() && !collector.includeDirectories) {
+		return nil
+	}
+	if collector.aggregateOnly {
+		// only aggregated per pattern
+		return fileinfo
+	}
+	if fileinfo.IsDir() {
+		c.collectDirMetrics(ctx, ch, file, metricLabels)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.fileSizeBytesDesc, prometheus.GaugeValue,
+			float64(fileinfo.Size()),
+			metricLabels...)
+	}
+	ch <- prometheus.MustNewConstMetric(c.fileModifTimeSecondsDesc, prometheus.GaugeValue,
+		timeToSeconds(fileinfo.ModTime()),
+		metricLabels...)
+
+	if collector.enableStatInfoMetric {
+		c.collectStatInfoMetric(ch, fileinfo, status.fileType, metricLabels)
+	}
+
+	// Metrics based on system specific stat
+	if !collector.hasExtendedStatMetric() {
+		return fileinfo
+	}
+	stat := extendedStat(file.realFilePath, fileinfo, collector.enableBirthTimeMetric)
+	if collector.enableAccessTimeMetric && !stat.accessTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.fileAccessTimeSecondsDesc, prometheus.GaugeValue,
+			timeToSeconds(stat.accessTime),
+			metricLabels...)
+	}
+	if collector.enableChangeTimeMetric && !stat.changeTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.fileChangeTimeSecondsDesc, prometheus.GaugeValue,
+			timeToSeconds(stat.changeTime),
+			metricLabels...)
+	}
+	if collector.enableBirthTimeMetric && !stat.birthTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.fileBirthTimeSecondsDesc, prometheus.GaugeValue,
+			timeToSeconds(stat.birthTime),
+			metricLabels...)
+	}
+	if collector.enableNlinkMetric && stat.hasNlink {
+		ch <- prometheus.MustNewConstMetric(c.fileNlinkDesc, prometheus.GaugeValue,
+			float64(stat.nlink),
+			metricLabels...)
+	}
+	if collector.enableAllocatedBytesMetric && stat.hasAllocatedBytes {
+		ch <- prometheus.MustNewConstMetric(c.fileAllocatedBytesDesc, prometheus.GaugeValue,
+			float64(stat.allocatedBytes),
+			metricLabels...)
+	}
+	return fileinfo
+}
+
+// Collect info metric about type, permissions and owner of a file
+func (c *filesCollector) collectStatInfoMetric(ch chan<- prometheus.Metric, fileinfo os.FileInfo, fileType os.FileMode, metricLabels []string) {
+	uid, gid, hasOwner := fileOwner(fileinfo)
+	user, group := "", ""
+	if hasOwner {
+		user = c.ownerNames.userName(uid)
+		group = c.ownerNames.groupName(gid)
+	}
+	infoLabels := slices.Concat(metricLabels, []string{fileModeOctal(fileinfo.Mode()), uid, gid, user, group, fileTypeName(fileType)})
+	ch <- prometheus.MustNewConstMetric(c.fileStatInfoDesc, prometheus.GaugeValue,
+		1,
+		infoLabels...)
+}
+
+// collect number of entries and size of directory - size is skipped when context is done
+func (c *filesCollector) collectDirMetrics(ctx context.Context, ch chan<- prometheus.Metric, file *treeFile, metricLabels []string) {
+	dirLabels := slices.Concat(metricLabels, []string{fileTypeName(os.ModeDir)})
+	if entries, err := os.ReadDir(file.realFilePath); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.fileDirEntriesDesc, prometheus.GaugeValue,
+			float64(len(entries)),
+			dirLabels...)
+	} else {
+		c.logger.Debug("Error reading directory", "path", file.realFilePath, "reason", err)
+		file.stats.addError(scrapeStageRead)
+	}
+	if size, err := file.dirSizes.size(ctx, file.realFilePath, file.collector.dirSizeMaxDepth); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.fileDirSizeBytesDesc, prometheus.GaugeValue,
+			float64(size),
+			dirLabels...)
+	} else if ctx.Err() == nil {
+		c.logger.Debug("Error getting size of directory", "path", file.realFilePath, "reason", err)
+		file.stats.addError(scrapeStageRead)
+	}
+}
+
+// Sizes of directories computed during a scrape - nested matching directories are walked once
+type dirSizeCache struct {
+	mutex sync.Mutex
+	sizes map[dirSizeKey]int64
+}
+
+// Directory and depth of its size
+type dirSizeKey struct {
+	dirPath  string
+	maxDepth int
+}
+
+func newDirSizeCache() *dirSizeCache {
+	return &dirSizeCache{sizes: make(map[dirSizeKey]int64)}
+}
+
+// apparent size of files in directory up to a depth - 0 is unlimited
+//
+// Symbolic links are not followed and unreadable subdirectories are ignored. Walk stops with
+// an error when context is done.
+func (cache *dirSizeCache) size(ctx context.Context, dirPath string, maxDepth int) (int64, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	key := dirSizeKey{dirPath: dirPath, maxDepth: maxDepth}
+	cache.mutex.Lock()
+	size, found := cache.sizes[key]
+	cache.mutex.Unlock()
+	if found {
+		return size, nil
+	}
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+			continue
+		}
+		if maxDepth == 1 {
+			continue
+		}
+		subSize, err := cache.size(ctx, filepath.Join(dirPath, entry.Name()), max(maxDepth-1, 0))
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if err == nil {
+			size += subSize
+		}
+	}
+
+	cache.mutex.Lock()
+	cache.sizes[key] = size
+	cache.mutex.Unlock()
+	return size, nil
+}
+
+// permissions of file in octal unix format
+func fileModeOctal(mode os.FileMode) string {
+	octal := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		octal |= 0o4000
+	}
+	if mode&os.ModeSetgid != 0 {
+		octal |= 0o2000
+	}
+	if mode&os.ModeSticky != 0 {
+		octal |= 0o1000
+	}
+	return fmt.Sprintf("%04o", octal)
+}
+
+// name of file type
+func fileTypeName(fileType os.FileMode) string {
+	switch {
+	case fileType&os.ModeDir != 0:
+		return "dir"
+	case fileType&os.ModeSymlink != 0:
+		return "symlink"
+	case fileType&os.ModeNamedPipe != 0:
+		return "fifo"
+	case fileType&os.ModeSocket != 0:
+		return "socket"
+	case fileType&os.ModeDevice != 0:
+		return "device"
+	case fileType&os.ModeIrregular != 0:
+		return "irregular"
+	default:
+		return "regular"
+	}
+}
+
+// Status of file according to symbolic link handling
+type fileStatus struct {
+	fileinfo  os.FileInfo // nil if file is skipped or link is broken
+	fileType  os.FileMode // type of file itself even if it is a symlink
+	isSymlink bool
+	target    string
+	broken    bool
+}
+
+// stat file according to symbolic link handling
+func (c *filesCollector) statFile(file *treeFile) (fileStatus, error) {
+	collector := file.collector
+	status := fileStatus{}
+	if collector.symlinks == symlinksFollow {
+		fileinfo, err := os.Stat(file.realFilePath)
+		if err != nil {
+			return status, err
+		}
+		status.fileinfo = fileinfo
+		status.fileType = fileinfo.Mode().Type()
+		if collector.enableStatInfoMetric {
+			if linkinfo, err := os.Lstat(file.realFilePath); err == nil {
+				status.fileType = linkinfo.Mode().Type()
+			}
+		}
+		return status, nil
+	}
+
+	linkinfo, err := os.Lstat(file.realFilePath)
+	if err != nil {
+		return status, err
+	}
+	status.fileType = linkinfo.Mode().Type()
+	if linkinfo.Mode()&os.ModeSymlink == 0 {
+		status.fileinfo = linkinfo
+		return status, nil
+	}
+	if collector.symlinks == symlinksSkip {
+		c.logger.Debug("Skip symbolic link", "path", file.realFilePath)
+		return status, nil
+	}
+
+	status.isSymlink = true
+	if status.target, err = os.Readlink(file.realFilePath); err != nil {
+		return status, err
+	}
+	if status.fileinfo, err = os.Stat(file.realFilePath); err != nil {
+		c.logger.Debug("Broken symbolic link", "path", file.realFilePath, "target", status.target, "reason", err)
+		status.fileinfo = nil
+		status.broken = true
+	}
+	return status, nil
+}
+
+// collect target of symbolic link and whether it is broken
+func (c *filesCollector) collectSymlinkMetrics(ch chan<- prometheus.Metric, status *fileStatus, metricLabels []string) {
+	broken := 0.0
+	if status.broken {
+		broken = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.fileSymlinkInfoDesc, prometheus.GaugeValue,
+		1,
+		slices.Concat(metricLabels, []string{status.target})...)
+	ch <- prometheus.MustNewConstMetric(c.fileSymlinkBrokenDesc, prometheus.GaugeValue,
+		broken,
+		metricLabels...)
+}
+
+// true if metrics need reading file content
+func (col *fileStatCollector) hasContentMetric() bool {
+	return col.enableContentTypeMetric || col.hasWholeContentMetric()
+}
+
+// true if metrics need reading file content until its end
+func (col *fileStatCollector) hasWholeContentMetric() bool {
+	return col.enableCRC32Metric ||
+		len(col.decompress) != 0 ||
+		col.enableLineNbMetric ||
+		len(col.hashAlgorithms) != 0 ||
+		len(col.lineMatchers) != 0 ||
+		len(col.extractors) != 0 ||
+		len(col.contentFormat) != 0
+}
+
+// true if metrics need more than os.FileInfo
+func (col *fileStatCollector) hasExtendedStatMetric() bool {
+	return col.enableAccessTimeMetric ||
+		col.enableChangeTimeMetric ||
+		col.enableBirthTimeMetric ||
+		col.enableNlinkMetric ||
+		col.enableAllocatedBytesMetric
+}
+
+// convert time to epoch time in seconds
+func timeToSeconds(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/1000000000.0
+}
+
+// Collect metrics for a file content
+func (c *filesCollector) collectContentMetrics(ctx context.Context, ch chan<- prometheus.Metric, file *treeFile, fileinfo os.FileInfo) {
+	collector := file.collector
+
+	// content of large files is not read
+	if collector.maxContentBytes != 0 && fileinfo.Size() > collector.maxContentBytes {
+		c.logger.Debug("Skip content of file larger than maximum", "path", file.realFilePath, "max_content_bytes", collector.maxContentBytes)
+		ch <- prometheus.MustNewConstMetric(c.fileContentSkippedDesc, prometheus.GaugeValue,
+			1,
+			slices.Concat([]string{file.filePath}, file.labels, []string{contentSkippedTooLarge})...)
+		return
+	}
+
+	// content is only read if file changed
+	key := newContentKey(fileinfo)
+	result, found := c.contentCache.get(file.realFilePath, key, collector.contentFingerprint)
+	if !found {
+		var err error
+		if result, err = c.readContent(ctx, file.realFilePath, collector, file.stats); err != nil {
+			if ctx.Err() == nil {
+				file.stats.addError(scrapeStageRead)
+			}
+			return
+		}
+		c.contentCache.put(file.realFilePath, key, collector.contentFingerprint, result)
+	}
+	file.digest, file.hasDigest = result.changeDigest()
+
+	metricLabels := slices.Concat([]string{file.filePath}, file.labels)
+	if len(result.contentType) != 0 {
+		ch <- prometheus.MustNewConstMetric(c.fileContentTypeInfoDesc, prometheus.GaugeValue,
+			1,
+			slices.Concat(metricLabels, []string{result.contentType})...)
+	}
+	// decompressed content larger than maximum
+	if result.tooLarge {
+		ch <- prometheus.MustNewConstMetric(c.fileContentSkippedDesc, prometheus.GaugeValue,
+			1,
+			slices.Concat(metricLabels, []string{contentSkippedTooLarge})...)
+		return
+	}
+	if len(collector.decompress) != 0 {
+		integrityError := 0.0
+		if result.integrityError {
+			integrityError = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.fileIntegrityErrorDesc, prometheus.GaugeValue,
+			integrityError,
+			metricLabels...)
+		if result.integrityError {
+			return
+		}
+		if result.decompressed {
+			ch <- prometheus.MustNewConstMetric(c.fileUncompressedBytesDesc, prometheus.GaugeValue,
+				float64(result.uncompressedBytes),
+				metricLabels...)
+		}
+	}
+	if result.hasCRC32 {
 		ch <- prometheus.MustNewConstMetric(c.fileCRC32HashDesc, prometheus.GaugeValue,
-			float64(hash.Sum32()),
+			float64(result.crc32),
 			metricLabels...)
 	}
-	if enableLineNb {
+	if collector.enableLineNbMetric {
 		ch <- prometheus.MustNewConstMetric(c.lineNbMetricDesc, prometheus.GaugeValue,
-			float64(lineNb),
+			float64(result.lineNb),
 			metricLabels...)
 	}
+	for _, digest := range result.digests {
+		ch <- prometheus.MustNewConstMetric(c.fileHashInfoDesc, prometheus.GaugeValue,
+			1,
+			slices.Concat(metricLabels, []string{digest.algorithm, hex.EncodeToString(digest.digest)})...)
+		if collector.enableHashTruncatedMetric {
+			ch <- prometheus.MustNewConstMetric(c.fileHashTruncatedDesc, prometheus.GaugeValue,
+				digest.truncated(),
+				slices.Concat(metricLabels, []string{digest.algorithm})...)
+		}
+	}
+	for _, match := range result.matches {
+		ch <- prometheus.MustNewConstMetric(c.fileMatchLinesDesc, prometheus.GaugeValue,
+			float64(match.matchLines),
+			slices.Concat(metricLabels, []string{match.name})...)
+		if collector.enableLastMatchLineMetric {
+			ch <- prometheus.MustNewConstMetric(c.fileLastMatchLineDesc, prometheus.GaugeValue,
+				float64(match.lastMatchLine),
+				slices.Concat(metricLabels, []string{match.name})...)
+		}
+	}
+	for _, extracted := range result.extracted {
+		if extracted.found {
+			ch <- prometheus.MustNewConstMetric(c.valueDescs[extracted.extractor.name], prometheus.GaugeValue,
+				extracted.value,
+				slices.Concat(metricLabels, extracted.labelValues)...)
+		}
+	}
+	if len(collector.contentFormat) != 0 {
+		parseError := 0.0
+		if result.parseError {
+			parseError = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.fileParseErrorDesc, prometheus.GaugeValue,
+			parseError,
+			metricLabels...)
+	}
+	for _, selected := range result.selected {
+		if selected.isNumber {
+			ch <- prometheus.MustNewConstMetric(c.valueDescs[selected.selector.name], prometheus.GaugeValue,
+				selected.value,
+				metricLabels...)
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.fileSelectorInfoDesc, prometheus.GaugeValue,
+				1,
+				slices.Concat(metricLabels, []string{selected.selector.name, selected.text})...)
+		}
+	}
+}
+
+// Read file content and compute content metrics of collector - stops with an error when context is done
+func (c *filesCollector) readContent(ctx context.Context, realFilePath string, collector *fileStatCollector, stats *treeScrapeStats) (contentResult, error) {
+	result := contentResult{}
+	file, err := os.Open(realFilePath)
+	if err != nil {
+		c.logger.Debug("Error getting content file hash while opening", "path", realFilePath, "reason", err)
+		return result, err
+	}
+	defer file.Close()
+
+	source := &countingReader{reader: file}
+	defer func() { stats.bytesRead.Add(uint64(source.count)) }()
+
+	// first bytes of content give its type
+	var head *contentHead
+	if collector.enableContentTypeMetric {
+		head = &contentHead{}
+		source.reader = io.TeeReader(file, head)
+		if !collector.hasWholeContentMetric() {
+			if _, err := io.ReadFull(source, make([]byte, contentTypeHeadSize)); source.err != nil {
+				c.logger.Debug("Error reading content of file", "path", realFilePath, "reason", err)
+				return result, source.err
+			}
+			result.contentType = head.contentType()
+			return result, nil
+		}
+	}
+
+	// content is decompressed if needed
+	reader := io.ReadCloser(io.NopCloser(source))
+	if len(collector.decompress) != 0 {
+		var format string
+		if reader, format, err = decompressReader(bufio.NewReader(source), collector.decompress, realFilePath); err != nil {
+			if source.err != nil {
+				c.logger.Debug("Error reading content of file", "path", realFilePath, "reason", source.err)
+				return result, source.err
+			}
+			c.logger.Debug("Error decompressing content of file", "path", realFilePath, "format", format, "reason", err)
+			return contentResult{decompressed: len(format) != 0, integrityError: true, contentType: head.contentType()}, nil
+		}
+		defer reader.Close()
+		result.decompressed = len(format) != 0
+	}
+
+	// decompressed content is limited too - one more byte is read to detect larger content
+	content := io.Reader(reader)
+	if result.decompressed && collector.maxContentBytes != 0 {
+		content = io.LimitReader(reader, collector.maxContentBytes+1)
+	}
+
+	enableCRC32 := collector.enableCRC32Metric
+	enableLineNb := collector.enableLineNbMetric
+	crc32Hash := crc32.NewIEEE()
+	hashes := make([]hash.Hash, len(collector.hashAlgorithms))
+	for i, algorithm := range collector.hashAlgorithms {
+		hashes[i] = hashAlgorithms[algorithm]()
+	}
+	lines := &lineSplitter{}
+	var matchCounter *lineMatchCounter
+	if len(collector.lineMatchers) != 0 {
+		matchCounter = newLineMatchCounter(collector.lineMatchers)
+		lines.handle(matchCounter.matchLine)
+	}
+	var extraction *valueExtraction
+	if len(collector.extractors) != 0 {
+		extraction = newValueExtraction(collector.extractors, lines)
+	}
+	needsContent := extraction != nil && extraction.needsContent()
+	var document *documentParser
+	if len(collector.contentFormat) != 0 {
+		document = &documentParser{format: collector.contentFormat, selectors: collector.selectors}
+	}
+
+	// read chunks of 32k
+	buf := make([]byte, 32*1024)
+	lineSep := []byte{'\n'}
+
+ReadFile:
+	for {
+		b, err := content.Read(buf)
+		slice := buf[:b]
+		result.uncompressedBytes += int64(b)
+		if result.decompressed && collector.maxContentBytes != 0 && result.uncompressedBytes > collector.maxContentBytes {
+			c.logger.Debug("Stop decompressing content of file larger than maximum", "path", realFilePath, "max_content_bytes", collector.maxContentBytes)
+			return contentResult{decompressed: true, tooLarge: true, contentType: head.contentType()}, nil
+		}
+		if enableLineNb {
+			result.lineNb += bytes.Count(slice, lineSep)
+		}
+		if enableCRC32 {
+			if _, errHash := crc32Hash.Write(slice); errHash != nil {
+				c.logger.Debug("Error generating CRC32 hash of file", "path", realFilePath, "reason", errHash)
+				enableCRC32 = false
+			}
+		}
+		for _, h := range hashes {
+			h.Write(slice)
+		}
+		if lines.isUsed() {
+			lines.Write(slice)
+		}
+		if needsContent {
+			extraction.Write(slice)
+		}
+		if document != nil {
+			document.Write(slice)
+		}
+
+		switch {
+		case err == io.EOF:
+			break ReadFile
+
+		case err != nil && source.err == nil && result.decompressed:
+			c.logger.Debug("Error decompressing content of file", "path", realFilePath, "reason", err)
+			return contentResult{decompressed: true, integrityError: true, contentType: head.contentType()}, nil
+
+		case err != nil:
+			c.logger.Debug("Error reading content of file", "path", realFilePath, "reason", err)
+			return result, err
+
+		case ctx.Err() != nil:
+			c.logger.Debug("Stop reading content of file at end of scrape", "path", realFilePath)
+			return result, ctx.Err()
+		}
+	}
+
+	result.contentType = head.contentType()
+	if enableCRC32 {
+		result.hasCRC32 = true
+		result.crc32 = crc32Hash.Sum32()
+	}
+	for i, h := range hashes {
+		result.digests = append(result.digests, contentDigest{algorithm: collector.hashAlgorithms[i], digest: h.Sum(nil)})
+	}
+	lines.close()
+	if matchCounter != nil {
+		result.matches = matchCounter.results
+	}
+	if extraction != nil {
+		result.extracted = extraction.close()
+	}
+	if document != nil {
+		if result.selected, err = document.close(); err != nil {
+			c.logger.Debug("Error parsing content of file", "path", realFilePath, "format", collector.contentFormat, "reason", err)
+			result.parseError = true
+		}
+	}
+	return result, nil
 }
@@ -0,0 +1,309 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// create files of sizes in root directory
+func createFiles(t *testing.T, root string, files map[string]int) {
+	for file, size := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, file)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, file), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// gather metrics of groups of files of tree root
+func gatherTree(t *testing.T, root string, files ...*collectorConfig) []*dto.MetricFamily {
+	cfg := configContent{}
+	cfg.Exporter.TreeRoot = &root
+	cfg.Exporter.Files = files
+	return gatherConfig(t, &cfg)
+}
+
+// gather metrics of trees of config
+func gatherConfig(t *testing.T, cfg *configContent) []*dto.MetricFamily {
+	if err := cfg.validate(); err != nil {
+		t.Fatal("Invalid config:", err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&scrapeCollector{ctx: context.Background(), collector: cfg.generateCollector(*slog.Default())})
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal("Error gathering metrics:", err)
+	}
+	return families
+}
+
+// value of metric with label value - false if not found
+func metricValue(families []*dto.MetricFamily, name string, label string, value string) (float64, bool) {
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == label && pair.GetValue() == value {
+					return metric.GetGauge().GetValue(), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+func TestApply_ShouldNotReusePreviousTemplate(t *testing.T) {
+	templater := newTemplater()
+
+	if _, err := apply(templater, "{{ add 1 1 }}/*.log"); err != nil {
+		t.Fatal("Error applying template:", err)
+	}
+	if result, err := apply(templater, ""); err != nil || result != "" {
+		t.Errorf("Empty pattern expanded to %q (error: %v)", result, err)
+	}
+	if result, err := apply(templater, "data/*.csv"); err != nil || result != "data/*.csv" {
+		t.Errorf("Pattern without template expanded to %q (error: %v)", result, err)
+	}
+}
+
+func TestFileModeOctal_ShouldIncludeSpecialBits(t *testing.T) {
+	if mode := fileModeOctal(0o644); mode != "0644" {
+		t.Error("Wrong mode:", mode)
+	}
+	if mode := fileModeOctal(os.ModeSetuid | os.ModeSticky | 0o755); mode != "5755" {
+		t.Error("Wrong mode with special bits:", mode)
+	}
+}
+
+// file info with only size and modification time
+type fakeFileInfo struct {
+	os.FileInfo
+	size    int64
+	modTime time.Time
+}
+
+func (f fakeFileInfo) Size() int64        { return f.size }
+func (f fakeFileInfo) ModTime() time.Time { return f.modTime }
+
+func TestGlobAggregate_ShouldAggregateSizeAndModificationTime(t *testing.T) {
+	aggregate := globAggregate{}
+
+	aggregate.add(fakeFileInfo{size: 10, modTime: time.Unix(200, 0)})
+	aggregate.add(fakeFileInfo{size: 30, modTime: time.Unix(100, 0)})
+	aggregate.add(fakeFileInfo{size: 20, modTime: time.Unix(300, 0)})
+
+	if aggregate.nbFiles != 3 || aggregate.sizeBytes != 60 || aggregate.largestBytes != 30 {
+		t.Error("Wrong size aggregate:", aggregate)
+	}
+	if aggregate.minModTime.Unix() != 100 || aggregate.maxModTime.Unix() != 300 || aggregate.sumModTime != 600 {
+		t.Error("Wrong modification time aggregate:", aggregate)
+	}
+}
+
+func TestContentDigestTruncated_ShouldKeepFirst48Bits(t *testing.T) {
+	digest := contentDigest{algorithm: "sha256", digest: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}}
+
+	if value := digest.truncated(); value != float64(0x010203040506) {
+		t.Errorf("Truncated digest is %v instead of %v", value, float64(0x010203040506))
+	}
+}
+
+func TestIsExcluded_ShouldMatchPathRelativeToTreeRoot(t *testing.T) {
+	excludePatterns := []string{"**/archive/**", "*.tmp"}
+
+	for filePath, expected := range map[string]bool{
+		"app/current.log":        false,
+		"app/archive/old.log":    true,
+		"archive/2024/01/a.log":  true,
+		"upload.tmp":             true,
+		"app/upload.tmp":         false,
+		"app/archive-manual.log": false,
+	} {
+		if isExcluded(excludePatterns, filePath) != expected {
+			t.Errorf("Exclusion of %q is not %v", filePath, expected)
+		}
+	}
+}
+
+func TestPathLabels_ShouldExtractNamedCapturesOfPath(t *testing.T) {
+	col := fileStatCollector{
+		pathLabelsRegex: regexp.MustCompile(`^data/(?P<customer>[^/]+)/(?P<year>\d{4})/`),
+		pathLabelGroups: []int{1, 0, 2},
+	}
+
+	if values, matched := col.pathLabels("data/acme/2026/10/report.csv"); !matched || !slices.Equal(values, []string{"acme", "", "2026"}) {
+		t.Errorf("Path labels are %q", values)
+	}
+	if values, matched := col.pathLabels("other/report.csv"); matched || !slices.Equal(values, []string{"", "", ""}) {
+		t.Errorf("Path labels of mismatching path are %q", values)
+	}
+}
+
+func TestDirSize_ShouldStopAtMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a", "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for file, size := range map[string]int{"top": 1, "a/middle": 10, "a/b/bottom": 100} {
+		if err := os.WriteFile(filepath.Join(root, file), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for maxDepth, expected := range map[int]int64{0: 111, 1: 1, 2: 11} {
+		if size, err := newDirSizeCache().size(context.Background(), root, maxDepth); err != nil || size != expected {
+			t.Errorf("Size of directory up to depth %d is %d instead of %d: %v", maxDepth, size, expected, err)
+		}
+	}
+}
+
+func TestDirSize_ShouldStopWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := newDirSizeCache().size(ctx, t.TempDir(), 0); err == nil {
+		t.Error("Size of directory computed after end of context")
+	}
+}
+
+func TestCollectTree_ShouldOnlyCountIncludedFilesInMaxFiles(t *testing.T) {
+	root := t.TempDir()
+	createFiles(t, root, map[string]int{"a/1.tmp": 1, "a/2.tmp": 1, "a/3.log": 1, "b/4.log": 1})
+	maxFiles := 2
+	files := &collectorConfig{GlobPatternPath: []string{"**"}, ExcludePatterns: []string{"**/*.tmp"}}
+	files.MaxFiles = &maxFiles
+
+	families := gatherTree(t, root, files)
+
+	if matches, _ := metricValue(families, "file_glob_match_number", "pattern", "**"); matches != 2 {
+		t.Errorf("Pattern matched %v files instead of 2", matches)
+	}
+	if limitReached, _ := metricValue(families, "file_glob_limit_reached", "pattern", "**"); limitReached != 0 {
+		t.Error("Limit reached while counting directories or excluded files")
+	}
+}
+
+func TestCollectTree_ShouldReportExistenceOfExpectedFiles(t *testing.T) {
+	root := t.TempDir()
+	createFiles(t, root, map[string]int{"present.csv": 1})
+	files := &collectorConfig{GlobPatternPath: []string{"*.log"}, ExpectedPaths: []string{"present.csv", "missing.csv", "absent.csv"}}
+
+	families := gatherTree(t, root, &collectorConfig{GlobPatternPath: []string{"*.txt"}}, files)
+
+	for path, expected := range map[string]float64{"present.csv": 1, "missing.csv": 0, "absent.csv": 0} {
+		if exists, found := metricValue(families, "file_exists", "path", path); !found || exists != expected {
+			t.Errorf("Existence of %s is %v instead of %v", path, exists, expected)
+		}
+	}
+	if missing, found := metricValue(families, "file_expected_missing", "pattern", "*.log"); !found || missing != 2 {
+		t.Errorf("Group has %v missing files instead of 2", missing)
+	}
+}
+
+func TestCollectTree_ShouldReportMissingFilesOfTreesWithSameName(t *testing.T) {
+	firstRoot, secondRoot := t.TempDir(), t.TempDir()
+	createFiles(t, secondRoot, map[string]int{"second.csv": 1})
+	treeName, noTree := "app", ""
+	cfg := configContent{}
+	cfg.Exporter.TreeName = &noTree
+	cfg.Exporter.Trees = []*treeConfig{
+		{TreeName: &treeName, TreeRoot: &firstRoot, Files: []*collectorConfig{{GlobPatternPath: []string{"*.log"}, ExpectedPaths: []string{"first.csv"}}}},
+		{TreeName: &treeName, TreeRoot: &secondRoot, Files: []*collectorConfig{{GlobPatternPath: []string{"*.csv"}, ExpectedPaths: []string{"second.csv"}}}},
+	}
+
+	families := gatherConfig(t, &cfg)
+
+	if missing, found := metricValue(families, "file_expected_missing", "pattern", "*.log"); !found || missing != 1 {
+		t.Errorf("First tree has %v missing files instead of 1", missing)
+	}
+	if missing, found := metricValue(families, "file_expected_missing", "pattern", "*.csv"); !found || missing != 0 {
+		t.Errorf("Second tree has %v missing files instead of 0", missing)
+	}
+}
+
+func TestCollectTree_ShouldOnlyAddTypeLabelToDirectoryMetrics(t *testing.T) {
+	root := t.TempDir()
+	createFiles(t, root, map[string]int{"spool/app.log": 10, "spool/queue/message": 5})
+	enabled := true
+	files := &collectorConfig{GlobPatternPath: []string{"spool/*"}}
+	files.IncludeDirectories = &enabled
+
+	families := gatherTree(t, root, files)
+
+	if entries, found := metricValue(families, "file_dir_entries", "type", "dir"); !found || entries != 1 {
+		t.Errorf("Directory has %v entries instead of 1", entries)
+	}
+	if size, found := metricValue(families, "file_dir_size_bytes", "type", "dir"); !found || size != 5 {
+		t.Errorf("Directory has size %v instead of 5", size)
+	}
+	for _, family := range families {
+		if family.GetName() == "file_dir_entries" || family.GetName() == "file_dir_size_bytes" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == "type" {
+					t.Errorf("Metric %s has type label", family.GetName())
+				}
+			}
+		}
+	}
+}
+
+func TestCollectTree_ShouldProvideExtendedStatMetrics(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Extended stat metrics depend on system")
+	}
+	root := t.TempDir()
+	createFiles(t, root, map[string]int{"app.log": 10})
+	enabled := true
+	files := &collectorConfig{GlobPatternPath: []string{"*.log"}}
+	files.EnableAccessTimeMetric = &enabled
+	files.EnableChangeTimeMetric = &enabled
+	files.EnableNlinkMetric = &enabled
+	files.EnableAllocatedBytesMetric = &enabled
+
+	families := gatherTree(t, root, files)
+
+	for _, name := range []string{
+		"file_stat_access_time_seconds",
+		"file_stat_change_time_seconds",
+		"file_stat_nlink",
+		"file_stat_allocated_bytes",
+	} {
+		if _, found := metricValue(families, name, "path", "app.log"); !found {
+			t.Errorf("Metric %s not provided", name)
+		}
+	}
+	if nlink, _ := metricValue(families, "file_stat_nlink", "path", "app.log"); nlink != 1 {
+		t.Errorf("File has %v links instead of 1", nlink)
+	}
+}
@@ -15,9 +15,15 @@
 package exporter
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -29,7 +35,16 @@ type configExporter struct {
 	ListenAddress string `yaml:"listen_address,omitempty"`
 	MetricsPath   string `yaml:"metrics_path,omitempty"`
 
+	CollectionConcurrency  int           `yaml:"collection_concurrency,omitempty"`
+	ContentCacheMaxEntries int           `yaml:"content_cache_max_entries,omitempty"`
+	ScrapeTimeout          time.Duration `yaml:"scrape_timeout,omitempty"`
+
+	StateFile          string        `yaml:"state_file,omitempty"`
+	StateWriteInterval time.Duration `yaml:"state_write_interval,omitempty"`
+
 	Trees []*treeConfig `yaml:"trees"`
+
+	Modules map[string]*treeConfig `yaml:"modules,omitempty"`
 }
 
 type configContent struct {
@@ -46,6 +61,7 @@ func (cfg *configContent) readFile(cfgFile string, logger slog.Logger) error {
 		if err != nil {
 			return err
 		}
+		defer r.Close()
 		decoder := yaml.NewDecoder(r)
 		decoder.KnownFields(true)
 		err = decoder.Decode(cfg)
@@ -101,6 +117,9 @@ func readConfig(cfgFile string, defaultCollector *treeConfig, logger slog.Logger
 			hasAtLeastOneTreeName = true
 		}
 	}
+	for _, module := range cfg.Exporter.Modules {
+		mergeModuleConfig(module, &cfg.Exporter.treeConfig)
+	}
 	// set default tree name is not configured
 	if hasAtLeastOneTreeName && cfg.Exporter.TreeName == nil {
 		logger.Info("Config", "from", "default", "tree_name", "<empty>")
@@ -133,24 +152,254 @@ func (cfg *configContent) toString() string {
 	return string(b)
 }
 
+// Check config can be used to generate a collector
+func (cfg *configContent) validate() error {
+	if len(cfg.Exporter.Files) == 0 && len(cfg.Exporter.Trees) == 0 && len(cfg.Exporter.Modules) == 0 {
+		return errors.New("filestat_exporter requires a config file with patterns, trees or modules or at least one argument file to match")
+	}
+
+	if cfg.Exporter.CollectionConcurrency < 0 {
+		return fmt.Errorf("invalid negative collection concurrency %d", cfg.Exporter.CollectionConcurrency)
+	}
+	if cfg.Exporter.ContentCacheMaxEntries < 0 {
+		return fmt.Errorf("invalid negative content cache size %d", cfg.Exporter.ContentCacheMaxEntries)
+	}
+	if cfg.Exporter.ScrapeTimeout < 0 {
+		return fmt.Errorf("invalid negative scrape timeout %s", cfg.Exporter.ScrapeTimeout)
+	}
+	if cfg.Exporter.StateWriteInterval < 0 {
+		return fmt.Errorf("invalid negative state write interval %s", cfg.Exporter.StateWriteInterval)
+	}
+
+	templater := newTemplater()
+	trees := append([]*treeConfig{&cfg.Exporter.treeConfig}, cfg.Exporter.Trees...)
+	for _, tree := range trees {
+		if len(tree.AllowedRoots) != 0 {
+			return errors.New("allowed roots are only supported by modules")
+		}
+	}
+	for name, module := range cfg.Exporter.Modules {
+		if module.hasChangeMetrics() {
+			return fmt.Errorf("change metrics are not supported by module %q", name)
+		}
+		trees = append(trees, module)
+	}
+	for _, tree := range trees {
+		if tree.TreeRoot != nil {
+			if _, err := templater.Parse(*tree.TreeRoot); err != nil {
+				return fmt.Errorf("invalid tree root template %q: %w", *tree.TreeRoot, err)
+			}
+		}
+		if err := tree.collectorConfig.validate(); err != nil {
+			return err
+		}
+		patterns := slices.Clone(tree.GlobPatternPath)
+		excludePatterns := slices.Clone(tree.ExcludePatterns)
+		expectedPaths := slices.Clone(tree.ExpectedPaths)
+		for _, colCfg := range tree.Files {
+			patterns = append(patterns, colCfg.GlobPatternPath...)
+			excludePatterns = append(excludePatterns, colCfg.ExcludePatterns...)
+			expectedPaths = append(expectedPaths, colCfg.ExpectedPaths...)
+			if err := colCfg.validate(); err != nil {
+				return err
+			}
+		}
+		for _, pattern := range patterns {
+			if _, err := templater.Parse(pattern); err != nil {
+				return fmt.Errorf("invalid pattern template %q: %w", pattern, err)
+			}
+		}
+		for _, pattern := range excludePatterns {
+			if _, err := templater.Parse(pattern); err != nil {
+				return fmt.Errorf("invalid exclude pattern template %q: %w", pattern, err)
+			}
+			if !strings.Contains(pattern, "{{") && !doublestar.ValidatePattern(pattern) {
+				return fmt.Errorf("invalid exclude pattern %q", pattern)
+			}
+		}
+		for _, expectedPath := range expectedPaths {
+			if _, err := templater.Parse(expectedPath); err != nil {
+				return fmt.Errorf("invalid expected path template %q: %w", expectedPath, err)
+			}
+		}
+	}
+
+	if err := checkCollectorMetrics(trees[:1+len(cfg.Exporter.Trees)]); err != nil {
+		return err
+	}
+	if err := checkExpectedGroups(trees[:1+len(cfg.Exporter.Trees)]); err != nil {
+		return err
+	}
+	for _, module := range cfg.Exporter.Modules {
+		if err := checkCollectorMetrics([]*treeConfig{module}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groups of files with expected paths are identified by tree name and patterns which must be unique
+func checkExpectedGroups(trees []*treeConfig) error {
+	groups := make(map[[2]string]struct{})
+	for _, tree := range trees {
+		treeName := ""
+		if tree.TreeName != nil {
+			treeName = *tree.TreeName
+		}
+		for _, colCfg := range tree.Files {
+			if len(colCfg.ExpectedPaths) == 0 && len(tree.ExpectedPaths) == 0 {
+				continue
+			}
+			group := [2]string{treeName, groupPattern(slices.Concat(colCfg.GlobPatternPath, tree.GlobPatternPath))}
+			if _, found := groups[group]; found {
+				return fmt.Errorf("groups of files with expected paths of tree %q have the same patterns %q", treeName, group[1])
+			}
+			groups[group] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// labels of a collector must be unique - path labels are not static labels and extract
+// and selector metrics with the same name have the same help and labels
+func checkCollectorMetrics(trees []*treeConfig) error {
+	labelNames := customLabelNames(trees)
+	pathLabelNames := pathLabelNames(trees)
+	for _, name := range pathLabelNames {
+		if slices.Contains(labelNames, name) {
+			return fmt.Errorf("path label %q is already a static label", name)
+		}
+	}
+	labelNames = append(labelNames, pathLabelNames...)
+	metrics := map[string]*valueExtractor{}
+	check := func(metric *valueExtractor) error {
+		for _, name := range metric.labelNames {
+			if slices.Contains(labelNames, name) {
+				return fmt.Errorf("label %q of metric %q is already a static or path label", name, metric.name)
+			}
+		}
+		other, found := metrics[metric.name]
+		if found && (other.help != metric.help || !slices.Equal(other.labelNames, metric.labelNames)) {
+			return fmt.Errorf("metric %q defined with different help or labels", metric.name)
+		}
+		metrics[metric.name] = metric
+		return nil
+	}
+	for _, tree := range trees {
+		for _, colCfg := range tree.Files {
+			for i := range colCfg.Extract {
+				extractor, err := newValueExtractor(&colCfg.Extract[i])
+				if err != nil {
+					return err
+				}
+				if err := check(extractor); err != nil {
+					return err
+				}
+			}
+			for i := range colCfg.Selectors {
+				selector, err := newDocumentSelector(&colCfg.Selectors[i])
+				if err != nil {
+					return err
+				}
+				if err := check(&valueExtractor{name: selector.name, help: selector.help}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // Generate collector from config
 func (cfg *configContent) generateCollector(logger slog.Logger) *filesCollector {
-	c := createFilesCollector(logger, (cfg.Exporter.TreeName != nil))
+	trees := append([]*treeConfig{&cfg.Exporter.treeConfig}, cfg.Exporter.Trees...)
+	c := generateTreesCollector(logger, (cfg.Exporter.TreeName != nil), trees)
+	c.useConcurrency(cfg.Exporter.CollectionConcurrency)
+	return c
+}
 
-	hasAtleastOneCRC32Metric := false
-	hasAtleastOneLineNbMetric := false
-	for _, colCfg := range cfg.Exporter.Files {
-		col := cfg.Exporter.treeConfig.createFileStatCollector(colCfg)
-		hasAtleastOneCRC32Metric = hasAtleastOneCRC32Metric || col.enableCRC32Metric
-		hasAtleastOneLineNbMetric = hasAtleastOneLineNbMetric || col.enableLineNbMetric
-		c.addFileStatCollector(cfg.Exporter.TreeName, col)
+// Generate collector of a module with target as tree root
+func (cfg *configContent) generateProbeCollector(logger slog.Logger, moduleName string, target string) (*filesCollector, error) {
+	module, found := cfg.Exporter.Modules[moduleName]
+	if !found {
+		return nil, fmt.Errorf("unknown module %q", moduleName)
 	}
+	if !module.allowsTarget(target) {
+		return nil, fmt.Errorf("target %q is not in allowed roots of module %q", target, moduleName)
+	}
+	tree := *module
+	// target comes from request - never executed as template
+	tree.TreeRoot = &target
+	tree.literalTreeRoot = true
 
-	for _, tree := range cfg.Exporter.Trees {
+	c := generateTreesCollector(logger, (tree.TreeName != nil), []*treeConfig{&tree})
+	c.useConcurrency(cfg.Exporter.CollectionConcurrency)
+	return c, nil
+}
+
+// Generate collector from trees config
+func generateTreesCollector(logger slog.Logger, hasTree bool, trees []*treeConfig) *filesCollector {
+	labelNames := customLabelNames(trees)
+	pathLabelNames := pathLabelNames(trees)
+	c := createFilesCollector(logger, hasTree, labelNames, pathLabelNames)
+
+	hasAtleastOneCRC32Metric := false
+	hasAtleastOneLineNbMetric := false
+	hasAtleastOneAccessTimeMetric := false
+	hasAtleastOneChangeTimeMetric := false
+	hasAtleastOneBirthTimeMetric := false
+	hasAtleastOneNlinkMetric := false
+	hasAtleastOneAllocatedBytesMetric := false
+	hasAtleastOneStatInfoMetric := false
+	hasAtleastOneAggregateOnly := false
+	hasAtleastOneHashInfoMetric := false
+	hasAtleastOneHashTruncatedMetric := false
+	hasAtleastOneMatchLinesMetric := false
+	hasAtleastOneLastMatchLineMetric := false
+	hasAtleastOneDocumentMetric := false
+	hasAtleastOneSymlinkMetric := false
+	hasAtleastOneDirMetric := false
+	hasAtleastOneFilesystemMetric := false
+	hasAtleastOneExpectedPath := false
+	hasAtleastOneMaxFiles := false
+	hasAtleastOneMaxContentBytes := false
+	hasAtleastOneDecompress := false
+	hasAtleastOneContentTypeMetric := false
+	hasAtleastOneChangeMetric := false
+	for _, tree := range trees {
 		for _, colCfg := range tree.Files {
-			col := tree.createFileStatCollector(colCfg)
+			col := tree.createFileStatCollector(colCfg, labelNames, pathLabelNames)
 			hasAtleastOneCRC32Metric = hasAtleastOneCRC32Metric || col.enableCRC32Metric
 			hasAtleastOneLineNbMetric = hasAtleastOneLineNbMetric || col.enableLineNbMetric
+			hasAtleastOneAccessTimeMetric = hasAtleastOneAccessTimeMetric || col.enableAccessTimeMetric
+			hasAtleastOneChangeTimeMetric = hasAtleastOneChangeTimeMetric || col.enableChangeTimeMetric
+			hasAtleastOneBirthTimeMetric = hasAtleastOneBirthTimeMetric || col.enableBirthTimeMetric
+			hasAtleastOneNlinkMetric = hasAtleastOneNlinkMetric || col.enableNlinkMetric
+			hasAtleastOneAllocatedBytesMetric = hasAtleastOneAllocatedBytesMetric || col.enableAllocatedBytesMetric
+			hasAtleastOneStatInfoMetric = hasAtleastOneStatInfoMetric || col.enableStatInfoMetric
+			hasAtleastOneAggregateOnly = hasAtleastOneAggregateOnly || col.aggregateOnly
+			hasAtleastOneHashInfoMetric = hasAtleastOneHashInfoMetric || len(col.hashAlgorithms) != 0
+			hasAtleastOneHashTruncatedMetric = hasAtleastOneHashTruncatedMetric || col.enableHashTruncatedMetric
+			hasAtleastOneMatchLinesMetric = hasAtleastOneMatchLinesMetric || len(col.lineMatchers) != 0
+			hasAtleastOneLastMatchLineMetric = hasAtleastOneLastMatchLineMetric || col.enableLastMatchLineMetric
+			for _, extractor := range col.extractors {
+				logger.Debug("Collector creation", "extract_metric", extractor.name)
+				c.useValueMetric(extractor.name, extractor.help, extractor.labelNames)
+			}
+			for _, selector := range col.selectors {
+				logger.Debug("Collector creation", "selector_metric", selector.name)
+				c.useValueMetric(selector.name, selector.help, nil)
+			}
+			hasAtleastOneDocumentMetric = hasAtleastOneDocumentMetric || len(col.contentFormat) != 0
+			hasAtleastOneSymlinkMetric = hasAtleastOneSymlinkMetric || col.symlinks == symlinksReport
+			hasAtleastOneDirMetric = hasAtleastOneDirMetric || col.includeDirectories
+			hasAtleastOneFilesystemMetric = hasAtleastOneFilesystemMetric || col.enableFilesystemMetric
+			hasAtleastOneExpectedPath = hasAtleastOneExpectedPath || len(col.expectedPaths) != 0
+			hasAtleastOneMaxFiles = hasAtleastOneMaxFiles || col.maxFiles != 0
+			hasAtleastOneMaxContentBytes = hasAtleastOneMaxContentBytes || (col.maxContentBytes != 0 && col.hasContentMetric())
+			hasAtleastOneDecompress = hasAtleastOneDecompress || len(col.decompress) != 0
+			hasAtleastOneContentTypeMetric = hasAtleastOneContentTypeMetric || col.enableContentTypeMetric
+			hasAtleastOneChangeMetric = hasAtleastOneChangeMetric || col.enableChangeMetrics
 			c.addFileStatCollector(tree.TreeName, col)
 		}
 	}
@@ -163,6 +412,90 @@ func (cfg *configContent) generateCollector(logger slog.Logger) *filesCollector
 		logger.Debug("Collector creation", "has_at_least_a_line_nb_metric", hasAtleastOneLineNbMetric)
 		c.useLineNbMetric()
 	}
+	if hasAtleastOneAccessTimeMetric {
+		logger.Debug("Collector creation", "has_at_least_an_access_time_metric", hasAtleastOneAccessTimeMetric)
+		c.useAccessTimeMetric()
+	}
+	if hasAtleastOneChangeTimeMetric {
+		logger.Debug("Collector creation", "has_at_least_a_change_time_metric", hasAtleastOneChangeTimeMetric)
+		c.useChangeTimeMetric()
+	}
+	if hasAtleastOneBirthTimeMetric {
+		logger.Debug("Collector creation", "has_at_least_a_birth_time_metric", hasAtleastOneBirthTimeMetric)
+		c.useBirthTimeMetric()
+	}
+	if hasAtleastOneNlinkMetric {
+		logger.Debug("Collector creation", "has_at_least_a_nlink_metric", hasAtleastOneNlinkMetric)
+		c.useNlinkMetric()
+	}
+	if hasAtleastOneAllocatedBytesMetric {
+		logger.Debug("Collector creation", "has_at_least_an_allocated_bytes_metric", hasAtleastOneAllocatedBytesMetric)
+		c.useAllocatedBytesMetric()
+	}
+	if hasAtleastOneStatInfoMetric {
+		logger.Debug("Collector creation", "has_at_least_a_stat_info_metric", hasAtleastOneStatInfoMetric)
+		c.useStatInfoMetric()
+	}
+	if hasAtleastOneAggregateOnly {
+		logger.Debug("Collector creation", "has_at_least_an_aggregate_only", hasAtleastOneAggregateOnly)
+		c.useAggregateMetrics()
+	}
+	if hasAtleastOneHashInfoMetric {
+		logger.Debug("Collector creation", "has_at_least_a_hash_info_metric", hasAtleastOneHashInfoMetric)
+		c.useHashInfoMetric()
+	}
+	if hasAtleastOneHashTruncatedMetric {
+		logger.Debug("Collector creation", "has_at_least_a_hash_truncated_metric", hasAtleastOneHashTruncatedMetric)
+		c.useHashTruncatedMetric()
+	}
+	if hasAtleastOneMatchLinesMetric {
+		logger.Debug("Collector creation", "has_at_least_a_match_lines_metric", hasAtleastOneMatchLinesMetric)
+		c.useMatchLinesMetric()
+	}
+	if hasAtleastOneLastMatchLineMetric {
+		logger.Debug("Collector creation", "has_at_least_a_last_match_line_metric", hasAtleastOneLastMatchLineMetric)
+		c.useLastMatchLineMetric()
+	}
+	if hasAtleastOneDocumentMetric {
+		logger.Debug("Collector creation", "has_at_least_a_document_metric", hasAtleastOneDocumentMetric)
+		c.useDocumentMetrics()
+	}
+	if hasAtleastOneSymlinkMetric {
+		logger.Debug("Collector creation", "has_at_least_a_symlink_metric", hasAtleastOneSymlinkMetric)
+		c.useSymlinkMetrics()
+	}
+	if hasAtleastOneDirMetric {
+		logger.Debug("Collector creation", "has_at_least_a_dir_metric", hasAtleastOneDirMetric)
+		c.useDirMetrics()
+	}
+	if hasAtleastOneFilesystemMetric {
+		logger.Debug("Collector creation", "has_at_least_a_filesystem_metric", hasAtleastOneFilesystemMetric)
+		c.useFilesystemMetrics()
+	}
+	if hasAtleastOneExpectedPath {
+		logger.Debug("Collector creation", "has_at_least_an_expected_path", hasAtleastOneExpectedPath)
+		c.useExpectedMetrics()
+	}
+	if hasAtleastOneMaxFiles {
+		logger.Debug("Collector creation", "has_at_least_a_max_files", hasAtleastOneMaxFiles)
+		c.useGlobLimitMetric()
+	}
+	if hasAtleastOneMaxContentBytes {
+		logger.Debug("Collector creation", "has_at_least_a_max_content_bytes", hasAtleastOneMaxContentBytes)
+		c.useContentSkippedMetric()
+	}
+	if hasAtleastOneDecompress {
+		logger.Debug("Collector creation", "has_at_least_a_decompress", hasAtleastOneDecompress)
+		c.useDecompressMetrics()
+	}
+	if hasAtleastOneContentTypeMetric {
+		logger.Debug("Collector creation", "has_at_least_a_content_type_metric", hasAtleastOneContentTypeMetric)
+		c.useContentTypeMetric()
+	}
+	if hasAtleastOneChangeMetric {
+		logger.Debug("Collector creation", "has_at_least_a_change_metric", hasAtleastOneChangeMetric)
+		c.useChangeMetrics()
+	}
 
 	return c
 }
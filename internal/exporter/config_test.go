@@ -0,0 +1,175 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestValidate_ShouldFailWhenNoPatterns(t *testing.T) {
+	cfg := configContent{}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config without patterns is valid")
+	}
+}
+
+func TestValidate_ShouldFailWhenPatternTemplateInvalid(t *testing.T) {
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.log", "{{ now"}}}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config with invalid template is valid")
+	}
+}
+
+func TestValidate_ShouldSucceedWhenPatternsValid(t *testing.T) {
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"{{ now.Year }}/*.log"}}}
+
+	if err := cfg.validate(); err != nil {
+		t.Error("Valid config rejected:", err)
+	}
+}
+
+func TestGenerateProbeCollector_ShouldFailWhenModuleUnknown(t *testing.T) {
+	cfg := configContent{}
+
+	if _, err := cfg.generateProbeCollector(*slog.Default(), "unknown", "a/path"); err == nil {
+		t.Error("Collector generated from unknown module")
+	}
+}
+
+func TestValidate_ShouldFailWhenHashAlgorithmUnknown(t *testing.T) {
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}
+	cfg.Exporter.Files[0].HashAlgorithms = []string{"sha256", "crc64"}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config with unknown hash algorithm is valid")
+	}
+}
+
+func TestValidate_ShouldFailWhenHashAlgorithmDuplicated(t *testing.T) {
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}
+	cfg.Exporter.Files[0].HashAlgorithms = []string{"sha256", "sha256"}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config with duplicate hash algorithm is valid")
+	}
+}
+
+func TestValidate_ShouldFailWhenModuleHasChangeMetrics(t *testing.T) {
+	enabled := true
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}
+	module := &collectorConfig{GlobPatternPath: []string{"*.log"}}
+	module.EnableChangeMetrics = &enabled
+	cfg.Exporter.Modules = map[string]*treeConfig{"logs": {Files: []*collectorConfig{module}}}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config with change metrics in module is valid")
+	}
+}
+
+func TestValidate_ShouldFailWhenTreeHasAllowedRoots(t *testing.T) {
+	cfg := configContent{}
+	cfg.Exporter.Trees = []*treeConfig{{Files: []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}, AllowedRoots: []string{"/var/log"}}}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config with allowed roots in tree is valid")
+	}
+}
+
+func TestValidate_ShouldFailWhenGroupsWithExpectedPathsHaveSamePatterns(t *testing.T) {
+	treeName := "app"
+	cfg := configContent{}
+	cfg.Exporter.Trees = []*treeConfig{
+		{TreeName: &treeName, Files: []*collectorConfig{{GlobPatternPath: []string{"*.log"}, ExpectedPaths: []string{"a.csv"}}}},
+		{TreeName: &treeName, Files: []*collectorConfig{{GlobPatternPath: []string{"*.log"}, ExpectedPaths: []string{"b.csv"}}}},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config with groups of expected paths with same tree and patterns is valid")
+	}
+}
+
+func TestValidate_ShouldFailWhenExtractNameReserved(t *testing.T) {
+	for _, name := range []string{"file_value", "filestat_value", "go_value", "process_value", "promhttp_value", "probe_value"} {
+		cfg := configContent{}
+		cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}
+		cfg.Exporter.Files[0].Extract = []extractConfig{{Name: name, Regex: `(\d+)`}}
+
+		if err := cfg.validate(); err == nil {
+			t.Errorf("Config with extract metric named %q is valid", name)
+		}
+	}
+}
+
+func TestValidate_ShouldSucceedWhenExtractNameNotReserved(t *testing.T) {
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}
+	cfg.Exporter.Files[0].Extract = []extractConfig{{Name: "app_value", Regex: `(\d+)`}}
+
+	if err := cfg.validate(); err != nil {
+		t.Error("Config with extract metric not using reserved prefix is invalid:", err)
+	}
+}
+
+func TestValidate_ShouldFailWhenSelectorNameReserved(t *testing.T) {
+	format := "json"
+	for _, name := range []string{"file_value", "filestat_value", "go_value", "process_value", "promhttp_value", "probe_value"} {
+		cfg := configContent{}
+		cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.json"}}}
+		cfg.Exporter.Files[0].ContentFormat = &format
+		cfg.Exporter.Files[0].Selectors = []selectorConfig{{Name: name, Path: "$.value"}}
+
+		if err := cfg.validate(); err == nil {
+			t.Errorf("Config with selector metric named %q is valid", name)
+		}
+	}
+}
+
+func TestValidate_ShouldSucceedWhenSelectorNameNotReserved(t *testing.T) {
+	format := "json"
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.json"}}}
+	cfg.Exporter.Files[0].ContentFormat = &format
+	cfg.Exporter.Files[0].Selectors = []selectorConfig{{Name: "app_value", Path: "$.value"}}
+
+	if err := cfg.validate(); err != nil {
+		t.Error("Config with selector metric not using reserved prefix is invalid:", err)
+	}
+}
+
+func TestValidate_ShouldFailWhenExcludePatternInvalid(t *testing.T) {
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"**/*.log"}, ExcludePatterns: []string{"[archive"}}}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config with invalid exclude pattern is valid")
+	}
+}
+
+func TestValidate_ShouldFailWhenExpectedPathTemplateInvalid(t *testing.T) {
+	cfg := configContent{}
+	cfg.Exporter.Files = []*collectorConfig{{GlobPatternPath: []string{"*.csv"}, ExpectedPaths: []string{"export-{{ now.Format }.csv"}}}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("Config with invalid expected path template is valid")
+	}
+}
@@ -15,18 +15,92 @@
 package exporter
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
 	"slices"
+	"strings"
 )
 
 type collectorMetricConfig struct {
-	EnableCRC32Metric  *bool `yaml:"enable_crc32_metric,omitempty"`
-	EnableNbLineMetric *bool `yaml:"enable_nb_line_metric,omitempty"`
+	EnableCRC32Metric          *bool `yaml:"enable_crc32_metric,omitempty"`
+	EnableNbLineMetric         *bool `yaml:"enable_nb_line_metric,omitempty"`
+	EnableAccessTimeMetric     *bool `yaml:"enable_access_time_metric,omitempty"`
+	EnableChangeTimeMetric     *bool `yaml:"enable_change_time_metric,omitempty"`
+	EnableBirthTimeMetric      *bool `yaml:"enable_birth_time_metric,omitempty"`
+	EnableNlinkMetric          *bool `yaml:"enable_nlink_metric,omitempty"`
+	EnableAllocatedBytesMetric *bool `yaml:"enable_allocated_bytes_metric,omitempty"`
+	EnableStatInfoMetric       *bool `yaml:"enable_stat_info_metric,omitempty"`
+	AggregateOnly              *bool `yaml:"aggregate_only,omitempty"`
+
+	HashAlgorithms            []string `yaml:"hash_algorithms,omitempty"`
+	EnableHashTruncatedMetric *bool    `yaml:"enable_hash_truncated_metric,omitempty"`
+
+	LineMatchers              []lineMatcherConfig `yaml:"line_matchers,omitempty"`
+	EnableLastMatchLineMetric *bool               `yaml:"enable_last_match_line_metric,omitempty"`
+
+	Extract []extractConfig `yaml:"extract,omitempty"`
+
+	ContentFormat *string          `yaml:"content_format,omitempty"`
+	Selectors     []selectorConfig `yaml:"selectors,omitempty"`
+
+	Symlinks *string `yaml:"symlinks,omitempty"`
+
+	IncludeDirectories *bool `yaml:"include_directories,omitempty"`
+	DirSizeMaxDepth    *int  `yaml:"dir_size_max_depth,omitempty"`
+
+	MaxFiles        *int   `yaml:"max_files,omitempty"`
+	MaxDepth        *int   `yaml:"max_depth,omitempty"`
+	MaxContentBytes *int64 `yaml:"max_content_bytes,omitempty"`
+
+	Decompress *string `yaml:"decompress,omitempty"`
+
+	EnableContentTypeMetric *bool `yaml:"enable_content_type_metric,omitempty"`
+	EnableChangeMetrics     *bool `yaml:"enable_change_metrics,omitempty"`
+}
+
+type lineMatcherConfig struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+type selectorConfig struct {
+	Name string `yaml:"name"`
+	Help string `yaml:"help,omitempty"`
+	Path string `yaml:"path"`
+}
+
+type extractConfig struct {
+	Name  string `yaml:"name"`
+	Help  string `yaml:"help,omitempty"`
+	Regex string `yaml:"regex,omitempty"`
+	Match string `yaml:"match,omitempty"`
 }
 
 type collectorConfig struct {
 	collectorMetricConfig `yaml:",inline"`
 
-	GlobPatternPath []string `yaml:"patterns"`
+	GlobPatternPath []string          `yaml:"patterns"`
+	ExcludePatterns []string          `yaml:"exclude,omitempty"`
+	ExpectedPaths   []string          `yaml:"expected,omitempty"`
+	Labels          map[string]string `yaml:"labels,omitempty"`
+
+	PathLabels         string `yaml:"path_labels,omitempty"`
+	PathLabelsMismatch string `yaml:"path_labels_mismatch,omitempty"`
+}
+
+// labels used by metrics of exporter
+var reservedLabelNames = []string{
+	"path", "pattern", "tree", "target", "mountpoint", "reason",
+	"mode", "uid", "gid", "user", "group", "type",
+	"algorithm", "digest", "matcher", "selector", "value", "mime",
+}
+
+// prefixes of metrics of exporter, of Go runtime, of process, of metrics handler and of probes
+var reservedMetricPrefixes = []string{
+	namespace + "_", exporterNamespace + "_", "go_", "process_", "promhttp_", "probe_",
 }
 
 type treeConfig struct {
@@ -35,22 +109,95 @@ type treeConfig struct {
 	TreeName *string            `yaml:"tree_name,omitempty"`
 	TreeRoot *string            `yaml:"tree_root,omitempty"`
 	Files    []*collectorConfig `yaml:"files"`
+
+	EnableFilesystemMetric *bool `yaml:"enable_filesystem_metric,omitempty"`
+
+	// directories containing targets of module - any target if empty
+	AllowedRoots []string `yaml:"allowed_roots,omitempty"`
+
+	// tree root is used as is - not a template
+	literalTreeRoot bool
+}
+
+// whether target is in one of allowed roots of module - paths are compared without resolving symbolic links
+func (tree *treeConfig) allowsTarget(target string) bool {
+	if len(tree.AllowedRoots) == 0 {
+		return true
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return false
+	}
+	for _, root := range tree.AllowedRoots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(absRoot, absTarget); err == nil && filepath.IsLocal(rel) {
+			return true
+		}
+	}
+	return false
 }
 
 func mergeTreeConfig(collectorTree *treeConfig, defaultTree *treeConfig) {
 	mergeCollectorMetrics(&collectorTree.collectorMetricConfig, &defaultTree.collectorMetricConfig)
+	collectorTree.Labels = mergeLabels(collectorTree.Labels, defaultTree.Labels)
 	if collectorTree.TreeName == nil && defaultTree.TreeName != nil {
 		collectorTree.TreeName = defaultTree.TreeName
 	}
 	if collectorTree.TreeRoot == nil && defaultTree.TreeRoot != nil {
 		collectorTree.TreeRoot = defaultTree.TreeRoot
 	}
+	if collectorTree.EnableFilesystemMetric == nil {
+		collectorTree.EnableFilesystemMetric = defaultTree.EnableFilesystemMetric
+	}
 
 	for _, collector := range collectorTree.Files {
 		mergeCollectorMetrics(&collector.collectorMetricConfig, &collectorTree.collectorMetricConfig)
+		collector.Labels = mergeLabels(collector.Labels, collectorTree.Labels)
+	}
+}
+
+// true if change metrics are enabled in tree or one of its groups of files
+func (tree *treeConfig) hasChangeMetrics() bool {
+	if tree.EnableChangeMetrics != nil && *tree.EnableChangeMetrics {
+		return true
+	}
+	for _, colCfg := range tree.Files {
+		if colCfg.EnableChangeMetrics != nil && *colCfg.EnableChangeMetrics {
+			return true
+		}
+	}
+	return false
+}
+
+// modules only inherit metrics config - tree root is given by probe target
+func mergeModuleConfig(moduleTree *treeConfig, defaultTree *treeConfig) {
+	// change metrics are not inherited - states of files of probes are not kept
+	enableChangeMetrics := moduleTree.EnableChangeMetrics
+	mergeCollectorMetrics(&moduleTree.collectorMetricConfig, &defaultTree.collectorMetricConfig)
+	moduleTree.EnableChangeMetrics = enableChangeMetrics
+	moduleTree.Labels = mergeLabels(moduleTree.Labels, defaultTree.Labels)
+	if moduleTree.EnableFilesystemMetric == nil {
+		moduleTree.EnableFilesystemMetric = defaultTree.EnableFilesystemMetric
+	}
+	for _, collector := range moduleTree.Files {
+		mergeCollectorMetrics(&collector.collectorMetricConfig, &moduleTree.collectorMetricConfig)
+		collector.Labels = mergeLabels(collector.Labels, moduleTree.Labels)
 	}
 }
 
+// labels with values of default labels if not set
+func mergeLabels(labels map[string]string, defaultLabels map[string]string) map[string]string {
+	if len(defaultLabels) == 0 {
+		return labels
+	}
+	merged := maps.Clone(defaultLabels)
+	maps.Copy(merged, labels)
+	return merged
+}
+
 func mergeCollectorMetrics(collector *collectorMetricConfig, defaultCollector *collectorMetricConfig) {
 	if collector.EnableCRC32Metric == nil {
 		collector.EnableCRC32Metric = defaultCollector.EnableCRC32Metric
@@ -58,21 +205,304 @@ func mergeCollectorMetrics(collector *collectorMetricConfig, defaultCollector *c
 	if collector.EnableNbLineMetric == nil {
 		collector.EnableNbLineMetric = defaultCollector.EnableNbLineMetric
 	}
+	if collector.EnableAccessTimeMetric == nil {
+		collector.EnableAccessTimeMetric = defaultCollector.EnableAccessTimeMetric
+	}
+	if collector.EnableChangeTimeMetric == nil {
+		collector.EnableChangeTimeMetric = defaultCollector.EnableChangeTimeMetric
+	}
+	if collector.EnableBirthTimeMetric == nil {
+		collector.EnableBirthTimeMetric = defaultCollector.EnableBirthTimeMetric
+	}
+	if collector.EnableNlinkMetric == nil {
+		collector.EnableNlinkMetric = defaultCollector.EnableNlinkMetric
+	}
+	if collector.EnableAllocatedBytesMetric == nil {
+		collector.EnableAllocatedBytesMetric = defaultCollector.EnableAllocatedBytesMetric
+	}
+	if collector.EnableStatInfoMetric == nil {
+		collector.EnableStatInfoMetric = defaultCollector.EnableStatInfoMetric
+	}
+	if collector.AggregateOnly == nil {
+		collector.AggregateOnly = defaultCollector.AggregateOnly
+	}
+	if collector.HashAlgorithms == nil {
+		collector.HashAlgorithms = defaultCollector.HashAlgorithms
+	}
+	if collector.EnableHashTruncatedMetric == nil {
+		collector.EnableHashTruncatedMetric = defaultCollector.EnableHashTruncatedMetric
+	}
+	if collector.LineMatchers == nil {
+		collector.LineMatchers = defaultCollector.LineMatchers
+	}
+	if collector.EnableLastMatchLineMetric == nil {
+		collector.EnableLastMatchLineMetric = defaultCollector.EnableLastMatchLineMetric
+	}
+	if collector.Extract == nil {
+		collector.Extract = defaultCollector.Extract
+	}
+	if collector.ContentFormat == nil {
+		collector.ContentFormat = defaultCollector.ContentFormat
+	}
+	if collector.Selectors == nil {
+		collector.Selectors = defaultCollector.Selectors
+	}
+	if collector.Symlinks == nil {
+		collector.Symlinks = defaultCollector.Symlinks
+	}
+	if collector.IncludeDirectories == nil {
+		collector.IncludeDirectories = defaultCollector.IncludeDirectories
+	}
+	if collector.DirSizeMaxDepth == nil {
+		collector.DirSizeMaxDepth = defaultCollector.DirSizeMaxDepth
+	}
+	if collector.MaxFiles == nil {
+		collector.MaxFiles = defaultCollector.MaxFiles
+	}
+	if collector.MaxDepth == nil {
+		collector.MaxDepth = defaultCollector.MaxDepth
+	}
+	if collector.MaxContentBytes == nil {
+		collector.MaxContentBytes = defaultCollector.MaxContentBytes
+	}
+	if collector.Decompress == nil {
+		collector.Decompress = defaultCollector.Decompress
+	}
+	if collector.EnableContentTypeMetric == nil {
+		collector.EnableContentTypeMetric = defaultCollector.EnableContentTypeMetric
+	}
+	if collector.EnableChangeMetrics == nil {
+		collector.EnableChangeMetrics = defaultCollector.EnableChangeMetrics
+	}
 }
 
-func (tree *treeConfig) createFileStatCollector(colCfg *collectorConfig) fileStatCollector {
+// Check config of files group
+func (colCfg *collectorConfig) validate() error {
+	for name := range colCfg.Labels {
+		if !labelNameRegex.MatchString(name) || strings.HasPrefix(name, "__") || slices.Contains(reservedLabelNames, name) {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	if len(colCfg.PathLabels) != 0 {
+		regex, err := regexp.Compile(colCfg.PathLabels)
+		if err != nil {
+			return fmt.Errorf("invalid path labels regex: %w", err)
+		}
+		for _, name := range regex.SubexpNames() {
+			if len(name) == 0 {
+				continue
+			}
+			if !labelNameRegex.MatchString(name) || strings.HasPrefix(name, "__") || slices.Contains(reservedLabelNames, name) {
+				return fmt.Errorf("invalid path label name %q", name)
+			}
+			if _, found := colCfg.Labels[name]; found {
+				return fmt.Errorf("path label %q is already a static label", name)
+			}
+		}
+	}
+	if colCfg.Symlinks != nil {
+		switch *colCfg.Symlinks {
+		case symlinksFollow, symlinksSkip, symlinksReport:
+		default:
+			return fmt.Errorf("invalid symlinks mode %q: must be follow, skip or report", *colCfg.Symlinks)
+		}
+	}
+	if colCfg.DirSizeMaxDepth != nil && *colCfg.DirSizeMaxDepth < 0 {
+		return fmt.Errorf("invalid negative directory size depth %d", *colCfg.DirSizeMaxDepth)
+	}
+	if colCfg.Decompress != nil {
+		if _, found := decompressors[*colCfg.Decompress]; !found && *colCfg.Decompress != decompressAuto && *colCfg.Decompress != decompressNone {
+			return fmt.Errorf("invalid decompress format %q: must be auto, gzip, zstd, bzip2, xz or none", *colCfg.Decompress)
+		}
+	}
+	if colCfg.MaxFiles != nil && *colCfg.MaxFiles < 0 {
+		return fmt.Errorf("invalid negative maximum number of files %d", *colCfg.MaxFiles)
+	}
+	if colCfg.MaxDepth != nil && *colCfg.MaxDepth < 0 {
+		return fmt.Errorf("invalid negative maximum depth %d", *colCfg.MaxDepth)
+	}
+	if colCfg.MaxContentBytes != nil && *colCfg.MaxContentBytes < 0 {
+		return fmt.Errorf("invalid negative maximum content bytes %d", *colCfg.MaxContentBytes)
+	}
+	switch colCfg.PathLabelsMismatch {
+	case "", "keep", "drop":
+	default:
+		return fmt.Errorf("invalid path labels mismatch policy %q: must be keep or drop", colCfg.PathLabelsMismatch)
+	}
+	for i, algorithm := range colCfg.HashAlgorithms {
+		if _, found := hashAlgorithms[algorithm]; !found {
+			return fmt.Errorf("unknown hash algorithm %q", algorithm)
+		}
+		if slices.Contains(colCfg.HashAlgorithms[:i], algorithm) {
+			return fmt.Errorf("duplicate hash algorithm %q", algorithm)
+		}
+	}
+	names := map[string]bool{}
+	for _, matcher := range colCfg.LineMatchers {
+		if len(matcher.Name) == 0 {
+			return fmt.Errorf("line matcher %q has no name", matcher.Regex)
+		}
+		if names[matcher.Name] {
+			return fmt.Errorf("duplicate line matcher name %q", matcher.Name)
+		}
+		names[matcher.Name] = true
+		if _, err := regexp.Compile(matcher.Regex); err != nil {
+			return fmt.Errorf("invalid regex of line matcher %q: %w", matcher.Name, err)
+		}
+	}
+	names = map[string]bool{}
+	for i := range colCfg.Extract {
+		extractor, err := newValueExtractor(&colCfg.Extract[i])
+		if err != nil {
+			return err
+		}
+		if names[extractor.name] {
+			return fmt.Errorf("duplicate extract metric name %q", extractor.name)
+		}
+		names[extractor.name] = true
+		if isReservedMetricName(extractor.name) {
+			return fmt.Errorf("extract metric name %q uses a prefix reserved by exporter", extractor.name)
+		}
+	}
+	if colCfg.ContentFormat != nil {
+		if _, found := documentFormats[*colCfg.ContentFormat]; !found {
+			return fmt.Errorf("unknown content format %q", *colCfg.ContentFormat)
+		}
+	} else if len(colCfg.Selectors) != 0 {
+		return errors.New("selectors require a content format")
+	}
+	for i := range colCfg.Selectors {
+		selector, err := newDocumentSelector(&colCfg.Selectors[i])
+		if err != nil {
+			return err
+		}
+		if names[selector.name] {
+			return fmt.Errorf("duplicate selector metric name %q", selector.name)
+		}
+		names[selector.name] = true
+		if isReservedMetricName(selector.name) {
+			return fmt.Errorf("selector metric name %q uses a prefix reserved by exporter", selector.name)
+		}
+	}
+	return nil
+}
+
+// true if metric name could conflict with metrics of exporter
+func isReservedMetricName(name string) bool {
+	return slices.ContainsFunc(reservedMetricPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(name, prefix)
+	})
+}
+
+// sorted names of labels of all files groups of trees
+func customLabelNames(trees []*treeConfig) []string {
+	names := map[string]struct{}{}
+	for _, tree := range trees {
+		for _, colCfg := range tree.Files {
+			for name := range colCfg.Labels {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+// sorted names of labels extracted from path by all files groups of trees
+func pathLabelNames(trees []*treeConfig) []string {
+	names := map[string]struct{}{}
+	for _, tree := range trees {
+		for _, colCfg := range tree.Files {
+			if regex, err := regexp.Compile(colCfg.PathLabels); err == nil {
+				for _, name := range regex.SubexpNames() {
+					if len(name) != 0 {
+						names[name] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+func (tree *treeConfig) createFileStatCollector(colCfg *collectorConfig, labelNames []string, pathLabelNames []string) fileStatCollector {
 	col := fileStatCollector{}
 
 	if tree.TreeName != nil {
 		col.labels = []string{*tree.TreeName}
 	}
+	for _, name := range labelNames {
+		col.labels = append(col.labels, colCfg.Labels[name])
+	}
 	if tree.TreeRoot != nil {
 		col.treeRoot = *tree.TreeRoot
 	}
+	col.literalTreeRoot = tree.literalTreeRoot
+	col.contentFingerprint = contentFingerprint(colCfg)
 	col.filesPatterns = slices.Concat(colCfg.GlobPatternPath, tree.GlobPatternPath)
+	col.excludePatterns = slices.Concat(colCfg.ExcludePatterns, tree.ExcludePatterns)
+	col.expectedPaths = slices.Concat(colCfg.ExpectedPaths, tree.ExpectedPaths)
+	col.pathLabelGroups = make([]int, len(pathLabelNames))
+	if len(colCfg.PathLabels) != 0 {
+		col.pathLabelsRegex = regexp.MustCompile(colCfg.PathLabels)
+		for i, name := range pathLabelNames {
+			col.pathLabelGroups[i] = col.pathLabelsRegex.SubexpIndex(name)
+		}
+	}
+	col.dropPathLabelsMismatch = colCfg.PathLabelsMismatch == "drop"
+	col.enableFilesystemMetric = tree.EnableFilesystemMetric != nil && *tree.EnableFilesystemMetric
+	col.includeDirectories = colCfg.IncludeDirectories != nil && *colCfg.IncludeDirectories
+	if colCfg.DirSizeMaxDepth != nil {
+		col.dirSizeMaxDepth = *colCfg.DirSizeMaxDepth
+	}
+	if colCfg.MaxFiles != nil {
+		col.maxFiles = *colCfg.MaxFiles
+	}
+	if colCfg.MaxDepth != nil {
+		col.maxDepth = *colCfg.MaxDepth
+	}
+	if colCfg.MaxContentBytes != nil {
+		col.maxContentBytes = *colCfg.MaxContentBytes
+	}
+	if colCfg.Decompress != nil && *colCfg.Decompress != decompressNone {
+		col.decompress = *colCfg.Decompress
+	}
+	col.symlinks = symlinksFollow
+	if colCfg.Symlinks != nil && len(*colCfg.Symlinks) != 0 {
+		col.symlinks = *colCfg.Symlinks
+	}
 
 	col.enableCRC32Metric = colCfg.EnableCRC32Metric != nil && *colCfg.EnableCRC32Metric
 	col.enableLineNbMetric = colCfg.EnableNbLineMetric != nil && *colCfg.EnableNbLineMetric
+	col.enableAccessTimeMetric = colCfg.EnableAccessTimeMetric != nil && *colCfg.EnableAccessTimeMetric
+	col.enableChangeTimeMetric = colCfg.EnableChangeTimeMetric != nil && *colCfg.EnableChangeTimeMetric
+	col.enableBirthTimeMetric = colCfg.EnableBirthTimeMetric != nil && *colCfg.EnableBirthTimeMetric
+	col.enableNlinkMetric = colCfg.EnableNlinkMetric != nil && *colCfg.EnableNlinkMetric
+	col.enableAllocatedBytesMetric = colCfg.EnableAllocatedBytesMetric != nil && *colCfg.EnableAllocatedBytesMetric
+	col.enableStatInfoMetric = colCfg.EnableStatInfoMetric != nil && *colCfg.EnableStatInfoMetric
+	col.enableContentTypeMetric = colCfg.EnableContentTypeMetric != nil && *colCfg.EnableContentTypeMetric
+	col.enableChangeMetrics = colCfg.EnableChangeMetrics != nil && *colCfg.EnableChangeMetrics
+	col.aggregateOnly = colCfg.AggregateOnly != nil && *colCfg.AggregateOnly
+	col.hashAlgorithms = slices.Clone(colCfg.HashAlgorithms)
+	col.enableHashTruncatedMetric = len(col.hashAlgorithms) != 0 &&
+		colCfg.EnableHashTruncatedMetric != nil && *colCfg.EnableHashTruncatedMetric
+	for _, matcher := range colCfg.LineMatchers {
+		col.lineMatchers = append(col.lineMatchers, lineMatcher{name: matcher.Name, regex: regexp.MustCompile(matcher.Regex)})
+	}
+	col.enableLastMatchLineMetric = len(col.lineMatchers) != 0 &&
+		colCfg.EnableLastMatchLineMetric != nil && *colCfg.EnableLastMatchLineMetric
+	for i := range colCfg.Extract {
+		if extractor, err := newValueExtractor(&colCfg.Extract[i]); err == nil {
+			col.extractors = append(col.extractors, extractor)
+		}
+	}
+	if colCfg.ContentFormat != nil {
+		col.contentFormat = *colCfg.ContentFormat
+		for i := range colCfg.Selectors {
+			if selector, err := newDocumentSelector(&colCfg.Selectors[i]); err == nil {
+				col.selectors = append(col.selectors, selector)
+			}
+		}
+	}
 
 	return col
 }
@@ -15,6 +15,8 @@
 package exporter
 
 import (
+	"maps"
+	"slices"
 	"testing"
 )
 
@@ -118,3 +120,92 @@ func TestMergeTreeConfig_ShouldSetCollectorMetricsFromDefaultAndTree(t *testing.
 		t.Error("EnableNbLineMetric not set from tree")
 	}
 }
+
+func TestMergeModuleConfig_ShouldOnlySetCollectorMetrics(t *testing.T) {
+	dh, defaultName, defaultRoot := true, "", "a/path"
+	defaultTree := treeConfig{
+		collectorConfig: collectorConfig{
+			collectorMetricConfig: collectorMetricConfig{EnableCRC32Metric: &dh, EnableChangeMetrics: &dh},
+		},
+		TreeName: &defaultName,
+		TreeRoot: &defaultRoot,
+	}
+	collector := collectorConfig{}
+	moduleTree := treeConfig{Files: []*collectorConfig{&collector}}
+
+	mergeModuleConfig(&moduleTree, &defaultTree)
+
+	if collector.EnableCRC32Metric != &dh {
+		t.Error("EnableCRC32Metric not set from default")
+	}
+	if collector.EnableChangeMetrics != nil {
+		t.Error("EnableChangeMetrics set from default")
+	}
+	if moduleTree.TreeName != nil {
+		t.Error("TreeName set from default")
+	}
+	if moduleTree.TreeRoot != nil {
+		t.Error("TreeRoot set from default")
+	}
+}
+
+func TestMergeCollectorMetrics_ShouldSetStatMetricsFromDefaultWhenNil(t *testing.T) {
+	da, dc, db, dn, dab := true, false, true, false, true
+	defaultCollector := collectorMetricConfig{
+		EnableAccessTimeMetric:     &da,
+		EnableChangeTimeMetric:     &dc,
+		EnableBirthTimeMetric:      &db,
+		EnableNlinkMetric:          &dn,
+		EnableAllocatedBytesMetric: &dab,
+	}
+	collector := collectorMetricConfig{}
+
+	mergeCollectorMetrics(&collector, &defaultCollector)
+
+	if collector.EnableAccessTimeMetric != &da {
+		t.Error("EnableAccessTimeMetric not set from default")
+	}
+	if collector.EnableChangeTimeMetric != &dc {
+		t.Error("EnableChangeTimeMetric not set from default")
+	}
+	if collector.EnableBirthTimeMetric != &db {
+		t.Error("EnableBirthTimeMetric not set from default")
+	}
+	if collector.EnableNlinkMetric != &dn {
+		t.Error("EnableNlinkMetric not set from default")
+	}
+	if collector.EnableAllocatedBytesMetric != &dab {
+		t.Error("EnableAllocatedBytesMetric not set from default")
+	}
+}
+
+func TestMergeTreeConfig_ShouldMergeLabelsOfGroups(t *testing.T) {
+	defaultTree := treeConfig{}
+	defaultTree.Labels = map[string]string{"env": "prod", "team": "ops"}
+	group := &collectorConfig{Labels: map[string]string{"team": "dev", "app": "web"}}
+	collectorTree := treeConfig{Files: []*collectorConfig{group}}
+
+	mergeTreeConfig(&collectorTree, &defaultTree)
+
+	expected := map[string]string{"env": "prod", "team": "dev", "app": "web"}
+	if !maps.Equal(group.Labels, expected) {
+		t.Errorf("Labels of group are %v instead of %v", group.Labels, expected)
+	}
+	if defaultTree.Labels["team"] != "ops" {
+		t.Error("Labels of default tree modified")
+	}
+}
+
+func TestCreateFileStatCollector_ShouldFillMissingLabelsWithEmptyValue(t *testing.T) {
+	web := &collectorConfig{Labels: map[string]string{"app": "web"}}
+	db := &collectorConfig{Labels: map[string]string{"team": "dba"}}
+	tree := &treeConfig{Files: []*collectorConfig{web, db}}
+
+	labelNames := customLabelNames([]*treeConfig{tree})
+	if !slices.Equal(labelNames, []string{"app", "team"}) {
+		t.Fatalf("Label names are %v", labelNames)
+	}
+	if col := tree.createFileStatCollector(db, labelNames, nil); !slices.Equal(col.labels, []string{"", "dba"}) {
+		t.Errorf("Labels of collector are %q", col.labels)
+	}
+}
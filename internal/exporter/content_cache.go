@@ -0,0 +1,191 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"container/list"
+	"os"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	contentCacheHitsOpts = prometheus.CounterOpts{
+		Namespace: exporterNamespace,
+		Subsystem: "content_cache",
+		Name:      "hits_total",
+		Help:      "Number of file content metrics found in cache",
+	}
+	contentCacheMissesOpts = prometheus.CounterOpts{
+		Namespace: exporterNamespace,
+		Subsystem: "content_cache",
+		Name:      "misses_total",
+		Help:      "Number of file content metrics not found in cache",
+	}
+)
+
+// Content metrics of a file
+type contentResult struct {
+	hasCRC32   bool
+	crc32      uint32
+	lineNb     int
+	digests    []contentDigest
+	matches    []lineMatchResult
+	extracted  []extractResult
+	parseError bool
+	selected   []selectorResult
+
+	decompressed      bool
+	uncompressedBytes int64
+	integrityError    bool
+	tooLarge          bool
+
+	contentType string
+}
+
+// Identity of file content - content is read again when it changes
+type contentKey struct {
+	device      uint64
+	inode       uint64
+	size        int64
+	modTimeNano int64
+}
+
+func newContentKey(fileinfo os.FileInfo) contentKey {
+	key := contentKey{
+		size:        fileinfo.Size(),
+		modTimeNano: fileinfo.ModTime().UnixNano(),
+	}
+	key.device, key.inode, _ = fileIdentity(fileinfo)
+	return key
+}
+
+// fingerprint of config of content metrics of a group of files
+//
+// Fingerprints are equal for groups computing the same content metrics, including across reloads.
+func contentFingerprint(colCfg *collectorConfig) uint64 {
+	content := collectorMetricConfig{
+		EnableCRC32Metric:       colCfg.EnableCRC32Metric,
+		EnableNbLineMetric:      colCfg.EnableNbLineMetric,
+		HashAlgorithms:          colCfg.HashAlgorithms,
+		LineMatchers:            colCfg.LineMatchers,
+		Extract:                 colCfg.Extract,
+		ContentFormat:           colCfg.ContentFormat,
+		Selectors:               colCfg.Selectors,
+		MaxContentBytes:         colCfg.MaxContentBytes,
+		Decompress:              colCfg.Decompress,
+		EnableContentTypeMetric: colCfg.EnableContentTypeMetric,
+	}
+	data, _ := yaml.Marshal(&content)
+	return xxhash.Sum64(data)
+}
+
+// Identity of an entry of cache - same file with different configs have different entries
+type contentCacheKey struct {
+	realFilePath string
+	fingerprint  uint64
+}
+
+// Content metrics computed for a file with a config of content metrics
+type contentCacheEntry struct {
+	cacheKey contentCacheKey
+	key      contentKey
+	result   contentResult
+}
+
+// LRU cache of content metrics - a nil cache is disabled
+type contentCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[contentCacheKey]*list.Element
+	lru        *list.List
+
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+func newContentCache() *contentCache {
+	return &contentCache{
+		entries: make(map[contentCacheKey]*list.Element),
+		lru:     list.New(),
+		hits:    prometheus.NewCounter(contentCacheHitsOpts),
+		misses:  prometheus.NewCounter(contentCacheMissesOpts),
+	}
+}
+
+// change maximum number of entries - 0 disables the cache
+func (cache *contentCache) resize(maxEntries int) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.maxEntries = maxEntries
+	cache.evict()
+}
+
+// get content metrics if file and config of content metrics didn't change
+func (cache *contentCache) get(realFilePath string, key contentKey, fingerprint uint64) (contentResult, bool) {
+	if cache == nil {
+		return contentResult{}, false
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.maxEntries == 0 {
+		return contentResult{}, false
+	}
+
+	if element, found := cache.entries[contentCacheKey{realFilePath, fingerprint}]; found {
+		entry := element.Value.(*contentCacheEntry)
+		if entry.key == key {
+			cache.lru.MoveToFront(element)
+			cache.hits.Inc()
+			return entry.result, true
+		}
+	}
+	cache.misses.Inc()
+	return contentResult{}, false
+}
+
+// store content metrics of file
+func (cache *contentCache) put(realFilePath string, key contentKey, fingerprint uint64, result contentResult) {
+	if cache == nil {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.maxEntries == 0 {
+		return
+	}
+
+	cacheKey := contentCacheKey{realFilePath, fingerprint}
+	entry := &contentCacheEntry{cacheKey: cacheKey, key: key, result: result}
+	if element, found := cache.entries[cacheKey]; found {
+		element.Value = entry
+		cache.lru.MoveToFront(element)
+		return
+	}
+	cache.entries[cacheKey] = cache.lru.PushFront(entry)
+	cache.evict()
+}
+
+// remove least recently used entries above maximum number of entries
+func (cache *contentCache) evict() {
+	for cache.lru.Len() > cache.maxEntries {
+		element := cache.lru.Back()
+		cache.lru.Remove(element)
+		delete(cache.entries, element.Value.(*contentCacheEntry).cacheKey)
+	}
+}
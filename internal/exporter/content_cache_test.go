@@ -0,0 +1,100 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+)
+
+func TestContentCache_ShouldHitWhenFileAndConfigUnchanged(t *testing.T) {
+	cache := newContentCache()
+	cache.resize(10)
+	key := contentKey{inode: 1, size: 10, modTimeNano: 100}
+
+	cache.put("a", key, 1, contentResult{lineNb: 3})
+
+	if result, found := cache.get("a", key, 1); !found || result.lineNb != 3 {
+		t.Error("Content not found in cache")
+	}
+	if _, found := cache.get("a", contentKey{inode: 1, size: 11, modTimeNano: 100}, 1); found {
+		t.Error("Content found in cache while size changed")
+	}
+	if _, found := cache.get("a", key, 2); found {
+		t.Error("Content found in cache while config changed")
+	}
+}
+
+func TestContentCache_ShouldKeepEntriesOfFileWithDifferentConfigs(t *testing.T) {
+	cache := newContentCache()
+	cache.resize(10)
+	key := contentKey{inode: 1, size: 10, modTimeNano: 100}
+
+	cache.put("a", key, 1, contentResult{lineNb: 3})
+	cache.put("a", key, 2, contentResult{lineNb: 4})
+
+	if result, found := cache.get("a", key, 1); !found || result.lineNb != 3 {
+		t.Error("Content of first config evicted by second config")
+	}
+}
+
+func TestContentFingerprint_ShouldOnlyDependOnContentConfig(t *testing.T) {
+	enabled := true
+	first := collectorConfig{GlobPatternPath: []string{"*.log"}}
+	first.EnableCRC32Metric = &enabled
+	second := collectorConfig{GlobPatternPath: []string{"**/*.log"}}
+	second.EnableCRC32Metric = &enabled
+	second.EnableStatInfoMetric = &enabled
+
+	if contentFingerprint(&first) != contentFingerprint(&second) {
+		t.Error("Fingerprints differ with same content config")
+	}
+	second.EnableNbLineMetric = &enabled
+	if contentFingerprint(&first) == contentFingerprint(&second) {
+		t.Error("Fingerprints equal with different content config")
+	}
+}
+
+func TestContentCache_ShouldEvictLeastRecentlyUsed(t *testing.T) {
+	cache := newContentCache()
+	cache.resize(2)
+	key := contentKey{}
+
+	cache.put("a", key, 0, contentResult{})
+	cache.put("b", key, 0, contentResult{})
+	cache.get("a", key, 0)
+	cache.put("c", key, 0, contentResult{})
+
+	if _, found := cache.get("b", key, 0); found {
+		t.Error("Least recently used entry not evicted")
+	}
+	if _, found := cache.get("a", key, 0); !found {
+		t.Error("Recently used entry evicted")
+	}
+}
+
+func TestContentCache_ShouldBeDisabledWhenNoEntries(t *testing.T) {
+	var nilCache *contentCache
+	cache := newContentCache()
+
+	nilCache.put("a", contentKey{}, 0, contentResult{})
+	cache.put("a", contentKey{}, 0, contentResult{})
+
+	if _, found := nilCache.get("a", contentKey{}, 0); found {
+		t.Error("Content found in nil cache")
+	}
+	if _, found := cache.get("a", contentKey{}, 0); found {
+		t.Error("Content found in empty cache")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"path"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Decompression of file content
+const (
+	decompressAuto = "auto"
+	decompressNone = "none"
+)
+
+// Decompressors of file content by compression format
+var decompressors = map[string]func(r io.Reader) (io.ReadCloser, error){
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"zstd": func(r io.Reader) (io.ReadCloser, error) {
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	},
+	"bzip2": func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	},
+	"xz": func(r io.Reader) (io.ReadCloser, error) {
+		reader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(reader), nil
+	},
+}
+
+// Magic bytes at start of compressed content
+var compressionMagics = []struct {
+	format string
+	magic  []byte
+}{
+	{"gzip", []byte{0x1f, 0x8b}},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+}
+
+// Extensions of compressed files
+var compressionExtensions = map[string]string{
+	".gz":  "gzip",
+	".zst": "zstd",
+	".bz2": "bzip2",
+	".xz":  "xz",
+}
+
+// number of first bytes of content needed to detect compression
+const compressionHeaderSize = 10
+
+// compression format from magic bytes of content or else from extension - empty if not compressed
+func detectCompression(header []byte, filePath string) string {
+	for _, compression := range compressionMagics {
+		if bytes.HasPrefix(header, compression.magic) {
+			return compression.format
+		}
+	}
+	if isBzip2Header(header) {
+		return "bzip2"
+	}
+	return compressionExtensions[path.Ext(filePath)]
+}
+
+// true if header is "BZh", a block size from 1 to 9 and the magic of a block or of end of stream
+func isBzip2Header(header []byte) bool {
+	if len(header) < compressionHeaderSize || !bytes.HasPrefix(header, []byte("BZh")) || header[3] < '1' || header[3] > '9' {
+		return false
+	}
+	magic := header[4:compressionHeaderSize]
+	return bytes.Equal(magic, []byte("1AY&SY")) || bytes.Equal(magic, []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90})
+}
+
+// reader of decompressed content of file - format is empty if content is read as is
+func decompressReader(r *bufio.Reader, decompress string, filePath string) (io.ReadCloser, string, error) {
+	format := decompress
+	if decompress == decompressAuto {
+		// error is io.EOF for short content
+		header, _ := r.Peek(compressionHeaderSize)
+		format = detectCompression(header, filePath)
+	}
+	newDecompressor, found := decompressors[format]
+	if !found {
+		return io.NopCloser(r), "", nil
+	}
+	reader, err := newDecompressor(r)
+	return reader, format, err
+}
+
+// Reader counting bytes read and keeping read error
+type countingReader struct {
+	reader io.Reader
+	count  int64
+	err    error
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
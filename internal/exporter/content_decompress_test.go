@@ -0,0 +1,71 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecompressReader_ShouldDetectFormatFromMagicBytes(t *testing.T) {
+	var gzipContent bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipContent)
+	gzipWriter.Write([]byte("line 1\nline 2\n"))
+	gzipWriter.Close()
+	zstdEncoder, _ := zstd.NewWriter(nil)
+	zstdContent := zstdEncoder.EncodeAll([]byte("line 1\n"), nil)
+
+	for _, test := range []struct {
+		content  []byte
+		format   string
+		expected string
+	}{
+		{gzipContent.Bytes(), "gzip", "line 1\nline 2\n"},
+		{zstdContent, "zstd", "line 1\n"},
+		{[]byte("plain"), "", "plain"},
+		{[]byte("BZh is not a bzip2 header\n"), "", "BZh is not a bzip2 header\n"},
+	} {
+		reader, format, err := decompressReader(bufio.NewReader(bytes.NewReader(test.content)), decompressAuto, "app.log.1")
+		if err != nil {
+			t.Fatalf("Failed to decompress %s content: %v", test.format, err)
+		}
+		content, err := io.ReadAll(reader)
+		if format != test.format || err != nil || string(content) != test.expected {
+			t.Errorf("Content decompressed as %s is %q (%v) instead of %q", format, content, err, test.expected)
+		}
+	}
+}
+
+func TestDetectCompression_ShouldRequireFullBzip2Header(t *testing.T) {
+	if format := detectCompression([]byte("BZh91AY&SY\x00"), "app.log.1"); format != "bzip2" {
+		t.Errorf("Bzip2 header detected as %q", format)
+	}
+	if format := detectCompression([]byte("BZh0 text file"), "app.log.1"); len(format) != 0 {
+		t.Errorf("Text starting with BZh detected as %q", format)
+	}
+}
+
+func TestDecompressReader_ShouldFailWhenContentCorrupted(t *testing.T) {
+	content := bufio.NewReader(bytes.NewReader([]byte("not compressed")))
+
+	if _, format, err := decompressReader(content, decompressAuto, "app.log.1.gz"); err == nil || format != "gzip" {
+		t.Errorf("Corrupted content decompressed as %q", format)
+	}
+}
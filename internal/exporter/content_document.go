@@ -0,0 +1,207 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// files larger than this are not parsed as documents
+const maxParsedFileLength = 1024 * 1024
+
+// Formats of structured documents
+var documentFormats = map[string]func(content []byte, document any) error{
+	"json": json.Unmarshal,
+	"yaml": yaml.Unmarshal,
+}
+
+// Step of selector - either a key of an object or an index of an array
+type selectorStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Named selector of a value in a structured document
+type documentSelector struct {
+	name  string
+	help  string
+	path  string
+	steps []selectorStep
+}
+
+// create selector from config
+func newDocumentSelector(cfg *selectorConfig) (*documentSelector, error) {
+	if !metricNameRegex.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("invalid selector metric name %q", cfg.Name)
+	}
+	steps, err := parseSelectorPath(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path of selector %q: %w", cfg.Name, err)
+	}
+	s := &documentSelector{name: cfg.Name, help: cfg.Help, path: cfg.Path, steps: steps}
+	if len(s.help) == 0 {
+		s.help = "Value selected in file content"
+	}
+	return s, nil
+}
+
+// parse JSONPath-style selector like $.queue.items[0]['name']
+func parseSelectorPath(path string) ([]selectorStep, error) {
+	rest, found := strings.CutPrefix(path, "$")
+	if !found {
+		return nil, errors.New("path must start with $")
+	}
+	steps := []selectorStep{}
+	for len(rest) != 0 {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key at %q", rest)
+			}
+			steps = append(steps, selectorStep{key: rest[1 : end+1]})
+			rest = rest[end+1:]
+
+		case strings.HasPrefix(rest, "['") || strings.HasPrefix(rest, `["`):
+			quote := rest[1:2] + "]"
+			end := strings.Index(rest[2:], quote)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated key at %q", rest)
+			}
+			steps = append(steps, selectorStep{key: rest[2 : end+2]})
+			rest = rest[end+4:]
+
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index at %q", rest)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index at %q", rest)
+			}
+			steps = append(steps, selectorStep{index: index, isIndex: true})
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("unexpected character at %q", rest)
+		}
+	}
+	return steps, nil
+}
+
+// Value selected in a document
+type selectorResult struct {
+	selector *documentSelector
+	isNumber bool
+	value    float64
+	text     string
+}
+
+// find value of selector in document - false if not found or not a scalar
+func (s *documentSelector) selectValue(document any) (selectorResult, bool) {
+	result := selectorResult{selector: s}
+	node := document
+	for _, step := range s.steps {
+		switch value := node.(type) {
+		case map[string]any:
+			if step.isIndex {
+				return result, false
+			}
+			child, found := value[step.key]
+			if !found {
+				return result, false
+			}
+			node = child
+		case []any:
+			if !step.isIndex || step.index >= len(value) {
+				return result, false
+			}
+			node = value[step.index]
+		default:
+			return result, false
+		}
+	}
+
+	switch value := node.(type) {
+	case float64:
+		result.isNumber, result.value = true, value
+	case int:
+		result.isNumber, result.value = true, float64(value)
+	case int64:
+		result.isNumber, result.value = true, float64(value)
+	case uint64:
+		result.isNumber, result.value = true, float64(value)
+	case bool:
+		result.isNumber = true
+		if value {
+			result.value = 1
+		}
+	case string:
+		result.text = value
+	default:
+		return result, false
+	}
+	return result, true
+}
+
+// Parse content as document and select values
+type documentParser struct {
+	format    string
+	selectors []*documentSelector
+	content   []byte
+	tooLong   bool
+}
+
+// keep content to be parsed
+func (p *documentParser) Write(chunk []byte) (int, error) {
+	if !p.tooLong {
+		if len(p.content)+len(chunk) > maxParsedFileLength {
+			p.tooLong = true
+			p.content = nil
+		} else {
+			p.content = append(p.content, chunk...)
+		}
+	}
+	return len(chunk), nil
+}
+
+// parse content and return selected values
+func (p *documentParser) close() ([]selectorResult, error) {
+	if p.tooLong {
+		return nil, fmt.Errorf("document larger than %d bytes", maxParsedFileLength)
+	}
+	var document any
+	if err := documentFormats[p.format](p.content, &document); err != nil {
+		return nil, err
+	}
+	results := []selectorResult{}
+	for _, selector := range p.selectors {
+		if result, found := selector.selectValue(document); found {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
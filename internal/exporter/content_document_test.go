@@ -0,0 +1,76 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+)
+
+func TestParseSelectorPath_ShouldParseKeysAndIndexes(t *testing.T) {
+	steps, err := parseSelectorPath(`$.queue.items[1]['a.b']["c"]`)
+	if err != nil {
+		t.Fatal("Valid selector path rejected:", err)
+	}
+
+	expected := []selectorStep{{key: "queue"}, {key: "items"}, {index: 1, isIndex: true}, {key: "a.b"}, {key: "c"}}
+	if len(steps) != len(expected) {
+		t.Fatalf("Parsed %d steps instead of %d", len(steps), len(expected))
+	}
+	for i := range expected {
+		if steps[i] != expected[i] {
+			t.Errorf("Step %d is %+v instead of %+v", i, steps[i], expected[i])
+		}
+	}
+}
+
+func TestParseSelectorPath_ShouldFailWhenNotFromRoot(t *testing.T) {
+	if _, err := parseSelectorPath("queue.depth"); err == nil {
+		t.Error("Selector path without root is valid")
+	}
+}
+
+func TestDocumentParser_ShouldSelectNumbersAndTexts(t *testing.T) {
+	for _, format := range []string{"json", "yaml"} {
+		depth, _ := newDocumentSelector(&selectorConfig{Name: "queue_depth", Path: "$.queue.depth"})
+		state, _ := newDocumentSelector(&selectorConfig{Name: "queue_state", Path: "$.queue.states[0]"})
+		missing, _ := newDocumentSelector(&selectorConfig{Name: "queue_size", Path: "$.queue.size"})
+		parser := documentParser{format: format, selectors: []*documentSelector{depth, state, missing}}
+
+		parser.Write([]byte(`{"queue":{"depth":42,"states":["running"]}}`))
+		results, err := parser.close()
+		if err != nil {
+			t.Fatalf("Valid %s document rejected: %v", format, err)
+		}
+
+		if len(results) != 2 {
+			t.Fatalf("Selected %d values in %s document instead of 2", len(results), format)
+		}
+		if !results[0].isNumber || results[0].value != 42 {
+			t.Errorf("Selected %+v in %s document instead of number 42", results[0], format)
+		}
+		if results[1].isNumber || results[1].text != "running" {
+			t.Errorf("Selected %+v in %s document instead of text running", results[1], format)
+		}
+	}
+}
+
+func TestDocumentParser_ShouldFailWhenDocumentMalformed(t *testing.T) {
+	parser := documentParser{format: "json"}
+
+	parser.Write([]byte(`{"queue":`))
+	if _, err := parser.close(); err == nil {
+		t.Error("Malformed document is valid")
+	}
+}
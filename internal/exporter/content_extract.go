@@ -0,0 +1,180 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// files larger than this are not parsed as a whole number
+const maxExtractedFileLength = 64 * 1024
+
+// name of capture group holding value when regex has several groups
+const extractValueGroup = "value"
+
+var (
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Extraction of numeric value from file content
+type valueExtractor struct {
+	name        string
+	help        string
+	regex       *regexp.Regexp // nil extracts whole trimmed content
+	last        bool
+	valueIndex  int
+	labelNames  []string
+	labelGroups []int
+}
+
+// create extractor from config
+func newValueExtractor(cfg *extractConfig) (*valueExtractor, error) {
+	e := &valueExtractor{name: cfg.Name, help: cfg.Help}
+	if !metricNameRegex.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("invalid extract metric name %q", cfg.Name)
+	}
+	if len(e.help) == 0 {
+		e.help = "Value extracted from file content"
+	}
+	switch cfg.Match {
+	case "", "first":
+	case "last":
+		e.last = true
+	default:
+		return nil, fmt.Errorf("invalid match %q of extract metric %q: must be first or last", cfg.Match, cfg.Name)
+	}
+	if len(cfg.Regex) == 0 {
+		return e, nil
+	}
+
+	regex, err := regexp.Compile(cfg.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex of extract metric %q: %w", cfg.Name, err)
+	}
+	if regex.NumSubexp() == 0 {
+		return nil, fmt.Errorf("regex of extract metric %q has no capture group", cfg.Name)
+	}
+	e.regex = regex
+	e.valueIndex = 1
+	if index := regex.SubexpIndex(extractValueGroup); index > 0 {
+		e.valueIndex = index
+	}
+	for i, name := range regex.SubexpNames() {
+		if i == e.valueIndex || len(name) == 0 {
+			continue
+		}
+		if !labelNameRegex.MatchString(name) || strings.HasPrefix(name, "__") || slices.Contains(reservedLabelNames, name) {
+			return nil, fmt.Errorf("invalid label %q of extract metric %q", name, cfg.Name)
+		}
+		if slices.Contains(e.labelNames, name) {
+			return nil, fmt.Errorf("duplicate label %q of extract metric %q", name, cfg.Name)
+		}
+		e.labelNames = append(e.labelNames, name)
+		e.labelGroups = append(e.labelGroups, i)
+	}
+	return e, nil
+}
+
+// Value extracted from file content
+type extractResult struct {
+	extractor   *valueExtractor
+	found       bool
+	value       float64
+	labelValues []string
+}
+
+// Extract values of file content
+type valueExtraction struct {
+	results []extractResult
+	content []byte
+	tooLong bool
+}
+
+func newValueExtraction(extractors []*valueExtractor, lines *lineSplitter) *valueExtraction {
+	x := &valueExtraction{results: make([]extractResult, len(extractors))}
+	for i, extractor := range extractors {
+		x.results[i].extractor = extractor
+		if extractor.regex != nil {
+			lines.handle(func(line []byte, _ int) { x.results[i].extractLine(line) })
+		}
+	}
+	return x
+}
+
+// true if whole content is needed
+func (x *valueExtraction) needsContent() bool {
+	for i := range x.results {
+		if x.results[i].extractor.regex == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// keep content for whole file extractors
+func (x *valueExtraction) Write(chunk []byte) (int, error) {
+	if !x.tooLong {
+		if len(x.content)+len(chunk) > maxExtractedFileLength {
+			x.tooLong = true
+			x.content = nil
+		} else {
+			x.content = append(x.content, chunk...)
+		}
+	}
+	return len(chunk), nil
+}
+
+// extract whole file values and return results
+func (x *valueExtraction) close() []extractResult {
+	for i := range x.results {
+		result := &x.results[i]
+		if result.extractor.regex != nil || x.tooLong {
+			continue
+		}
+		if value, err := strconv.ParseFloat(string(bytes.TrimSpace(x.content)), 64); err == nil {
+			result.found = true
+			result.value = value
+		}
+	}
+	return x.results
+}
+
+// extract value of line if it matches - lines without a number are ignored
+func (result *extractResult) extractLine(line []byte) {
+	extractor := result.extractor
+	if result.found && !extractor.last {
+		return
+	}
+	groups := extractor.regex.FindSubmatch(line)
+	if groups == nil {
+		return
+	}
+	value, err := strconv.ParseFloat(string(bytes.TrimSpace(groups[extractor.valueIndex])), 64)
+	if err != nil {
+		return
+	}
+	result.found = true
+	result.value = value
+	result.labelValues = make([]string, len(extractor.labelGroups))
+	for i, group := range extractor.labelGroups {
+		result.labelValues[i] = string(groups[group])
+	}
+}
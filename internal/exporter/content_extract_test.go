@@ -0,0 +1,63 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+)
+
+func TestValueExtraction_ShouldExtractLastMatchWithLabels(t *testing.T) {
+	extractor, err := newValueExtractor(&extractConfig{
+		Name:  "job_last_success",
+		Regex: `^(?P<job>\w+)_success=(?P<value>\S+)`,
+		Match: "last",
+	})
+	if err != nil {
+		t.Fatal("Valid extract config rejected:", err)
+	}
+	lines := &lineSplitter{}
+	extraction := newValueExtraction([]*valueExtractor{extractor}, lines)
+
+	lines.Write([]byte("backup_success=10\ncleanup_success=none\nsync_success=30\nother=40\n"))
+	lines.close()
+	results := extraction.close()
+
+	if !results[0].found || results[0].value != 30 || results[0].labelValues[0] != "sync" {
+		t.Errorf("Extracted %+v instead of value 30 of job sync", results[0])
+	}
+}
+
+func TestValueExtraction_ShouldExtractWholeTrimmedFile(t *testing.T) {
+	extractor, err := newValueExtractor(&extractConfig{Name: "job_count"})
+	if err != nil {
+		t.Fatal("Valid extract config rejected:", err)
+	}
+	lines := &lineSplitter{}
+	extraction := newValueExtraction([]*valueExtractor{extractor}, lines)
+
+	extraction.Write([]byte("  42"))
+	extraction.Write([]byte(".5\n"))
+	results := extraction.close()
+
+	if !results[0].found || results[0].value != 42.5 {
+		t.Errorf("Extracted %+v instead of value 42.5", results[0])
+	}
+}
+
+func TestNewValueExtractor_ShouldFailWhenRegexHasNoCaptureGroup(t *testing.T) {
+	if _, err := newValueExtractor(&extractConfig{Name: "job_count", Regex: `count=\d+`}); err == nil {
+		t.Error("Extract regex without capture group is valid")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Hash algorithms of file content by name
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"xxh64":  func() hash.Hash { return xxhash.New() },
+}
+
+// Digest of file content
+type contentDigest struct {
+	algorithm string
+	digest    []byte
+}
+
+// first 48 bits of digest - exactly represented as float metric
+func (d *contentDigest) truncated() float64 {
+	var prefix [8]byte
+	copy(prefix[2:], d.digest)
+	return float64(binary.BigEndian.Uint64(prefix[:]))
+}
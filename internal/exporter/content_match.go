@@ -0,0 +1,126 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// lines longer than this are truncated before matching
+const maxMatchedLineLength = 1024 * 1024
+
+// Split content written by chunks into lines given to handlers
+type lineSplitter struct {
+	handlers []func(line []byte, lineNb int)
+	pending  []byte
+	lineNb   int
+}
+
+// add handler of each line - line numbers start at 1
+func (s *lineSplitter) handle(handler func(line []byte, lineNb int)) {
+	s.handlers = append(s.handlers, handler)
+}
+
+// true if some handler needs lines
+func (s *lineSplitter) isUsed() bool {
+	return len(s.handlers) != 0
+}
+
+// handle complete lines of chunk - the last incomplete line is kept for next chunk
+func (s *lineSplitter) Write(chunk []byte) (int, error) {
+	n := len(chunk)
+	for {
+		end := bytes.IndexByte(chunk, '\n')
+		if end < 0 {
+			break
+		}
+		if len(s.pending) != 0 {
+			s.appendPending(chunk[:end])
+			s.handleLine(s.pending)
+			s.pending = s.pending[:0]
+		} else {
+			s.handleLine(chunk[:end])
+		}
+		chunk = chunk[end+1:]
+	}
+	s.appendPending(chunk)
+	return n, nil
+}
+
+// handle last line if not terminated by a newline
+func (s *lineSplitter) close() {
+	if len(s.pending) != 0 {
+		s.handleLine(s.pending)
+		s.pending = nil
+	}
+}
+
+func (s *lineSplitter) appendPending(part []byte) {
+	if room := maxMatchedLineLength - len(s.pending); len(part) > room {
+		part = part[:room]
+	}
+	s.pending = append(s.pending, part...)
+}
+
+func (s *lineSplitter) handleLine(line []byte) {
+	s.lineNb++
+	if len(line) > maxMatchedLineLength {
+		line = line[:maxMatchedLineLength]
+	}
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	for _, handler := range s.handlers {
+		handler(line, s.lineNb)
+	}
+}
+
+// Named regular expression matched against lines of file content
+type lineMatcher struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+// Result of a line matcher on file content
+type lineMatchResult struct {
+	name          string
+	matchLines    int
+	lastMatchLine int
+}
+
+// Count lines matching each matcher
+type lineMatchCounter struct {
+	matchers []lineMatcher
+	results  []lineMatchResult
+}
+
+func newLineMatchCounter(matchers []lineMatcher) *lineMatchCounter {
+	m := &lineMatchCounter{
+		matchers: matchers,
+		results:  make([]lineMatchResult, len(matchers)),
+	}
+	for i, matcher := range matchers {
+		m.results[i].name = matcher.name
+	}
+	return m
+}
+
+func (m *lineMatchCounter) matchLine(line []byte, lineNb int) {
+	for i, matcher := range m.matchers {
+		if matcher.regex.Match(line) {
+			m.results[i].matchLines++
+			m.results[i].lastMatchLine = lineNb
+		}
+	}
+}
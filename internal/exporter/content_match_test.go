@@ -0,0 +1,42 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestLineMatchCounter_ShouldMatchLinesSplitAcrossChunks(t *testing.T) {
+	m := newLineMatchCounter([]lineMatcher{
+		{name: "error", regex: regexp.MustCompile(`^ERROR`)},
+		{name: "warn", regex: regexp.MustCompile(`WARN$`)},
+	})
+	lines := lineSplitter{}
+	lines.handle(m.matchLine)
+
+	lines.Write([]byte("ERROR first\nINFO second\nER"))
+	lines.Write([]byte("ROR third\r\nlast WA"))
+	lines.Write([]byte("RN"))
+	lines.close()
+	results := m.results
+
+	if results[0].matchLines != 2 || results[0].lastMatchLine != 3 {
+		t.Errorf("Matcher error got %d lines, last %d", results[0].matchLines, results[0].lastMatchLine)
+	}
+	if results[1].matchLines != 1 || results[1].lastMatchLine != 4 {
+		t.Errorf("Matcher warn got %d lines, last %d", results[1].matchLines, results[1].lastMatchLine)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// number of first bytes of content used to detect its type
+const contentTypeHeadSize = 512
+
+// Magic bytes at offset of content of a type
+type contentSignature struct {
+	offset int
+	magic  []byte
+	mime   string
+}
+
+// Signatures of content types - first matching signature is used
+var contentSignatures = []contentSignature{
+	{0, []byte("%PDF-"), "application/pdf"},
+	{0, []byte("%!PS"), "application/postscript"},
+	{0, []byte("PK\x03\x04"), "application/zip"},
+	{0, []byte("PK\x05\x06"), "application/zip"},
+	{0, []byte{0x1f, 0x8b}, "application/gzip"},
+	{0, []byte{0x28, 0xb5, 0x2f, 0xfd}, "application/zstd"},
+	{0, []byte("BZh"), "application/x-bzip2"},
+	{0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "application/x-xz"},
+	{0, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}, "application/x-7z-compressed"},
+	{0, []byte("Rar!\x1a\x07"), "application/vnd.rar"},
+	{257, []byte("ustar"), "application/x-tar"},
+	{0, []byte("\x89PNG\r\n\x1a\n"), "image/png"},
+	{0, []byte{0xff, 0xd8, 0xff}, "image/jpeg"},
+	{0, []byte("GIF87a"), "image/gif"},
+	{0, []byte("GIF89a"), "image/gif"},
+	{8, []byte("WEBP"), "image/webp"},
+	{4, []byte("ftyp"), "video/mp4"},
+	{0, []byte("OggS"), "audio/ogg"},
+	{0, []byte("ID3"), "audio/mpeg"},
+	{0, []byte("\x7fELF"), "application/x-elf"},
+	{0, []byte("SQLite format 3\x00"), "application/vnd.sqlite3"},
+	{0, []byte("<?xml"), "text/xml"},
+}
+
+// Starts of HTML documents - compared in lower case after leading spaces
+var htmlPrefixes = [][]byte{
+	[]byte("<!doctype html"),
+	[]byte("<html"),
+	[]byte("<head"),
+	[]byte("<body"),
+}
+
+// type of content from its first bytes
+func detectContentType(head []byte) string {
+	if len(head) == 0 {
+		return "application/x-empty"
+	}
+	for _, signature := range contentSignatures {
+		if len(head) >= signature.offset && bytes.HasPrefix(head[signature.offset:], signature.magic) {
+			return signature.mime
+		}
+	}
+	start := bytes.ToLower(bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n"))
+	for _, prefix := range htmlPrefixes {
+		if bytes.HasPrefix(start, prefix) {
+			return "text/html"
+		}
+	}
+	if isText(head) {
+		return "text/plain"
+	}
+	return "application/octet-stream"
+}
+
+// true if content is UTF-8 without control characters other than spaces
+func isText(head []byte) bool {
+	// last character may be cut
+	for i := 0; i < utf8.UTFMax && len(head) != 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	if !utf8.Valid(head) {
+		return false
+	}
+	for _, b := range head {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' {
+			return false
+		}
+	}
+	return true
+}
+
+// Writer keeping first bytes of content
+type contentHead struct {
+	head []byte
+}
+
+func (h *contentHead) Write(p []byte) (int, error) {
+	if room := contentTypeHeadSize - len(h.head); room > 0 {
+		h.head = append(h.head, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// type of content from first bytes written - empty if not kept
+func (h *contentHead) contentType() string {
+	if h == nil {
+		return ""
+	}
+	return detectContentType(h.head)
+}
@@ -0,0 +1,49 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectContentType_ShouldUseSignaturesAndText(t *testing.T) {
+	tar := make([]byte, 300)
+	copy(tar[257:], "ustar")
+	for content, expected := range map[string]string{
+		"":                                    "application/x-empty",
+		"%PDF-1.7\n":                          "application/pdf",
+		"PK\x03\x04\x14\x00":                  "application/zip",
+		string(tar):                           "application/x-tar",
+		"\n  <!DOCTYPE html><html></html>":    "text/html",
+		"<HTML><body>Not Found</body></HTML>": "text/html",
+		"line 1\nligne 2 \u00e9t\u00e9\n":     "text/plain",
+		"\x00\x01\x02\x03":                    "application/octet-stream",
+	} {
+		if mime := detectContentType([]byte(content)); mime != expected {
+			t.Errorf("Type of content %q is %s instead of %s", content, mime, expected)
+		}
+	}
+}
+
+func TestContentHead_ShouldKeepFirstBytes(t *testing.T) {
+	head := &contentHead{}
+	head.Write(bytes.Repeat([]byte("a"), contentTypeHeadSize-1))
+	head.Write([]byte("bc"))
+
+	if len(head.head) != contentTypeHeadSize || head.head[contentTypeHeadSize-1] != 'b' {
+		t.Errorf("Head of content has %d bytes", len(head.head))
+	}
+}
@@ -15,12 +15,18 @@
 package exporter
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"runtime"
+	"syscall"
+	"time"
 
 	_ "net/http/pprof"
 
@@ -38,6 +44,11 @@ const (
 	defaultListenAddress = ":9943"
 	defaultMetricsPath   = "/metrics"
 	defaultNoTree        = "-none-"
+	defaultStateInterval = time.Minute
+	shutdownTimeout      = 10 * time.Second
+	defaultTimeoutOffset = 0.5
+	reloadPath           = "/-/reload"
+	probePath            = "/probe"
 )
 
 func Main() int {
@@ -54,6 +65,7 @@ func Main() int {
 		metricsPath   = commandLine.String("web.telemetry-path", defaultMetricsPath, "The path under which to expose metrics.")
 		treeName      = commandLine.String("tree.name", defaultNoTree, "Name of tree label to use - default if no label")
 		treeRoot      = commandLine.String("tree.root", "", "Path to use as root of patterns")
+		timeoutOffset = commandLine.Float64("scrape.timeout-offset", defaultTimeoutOffset, "Offset in seconds to subtract from timeout of Prometheus scrape.")
 	)
 	webConfig := web.FlagConfig{
 		WebListenAddresses: func() *[]string { a := make([]string, 1); return &a }(),
@@ -101,16 +113,15 @@ func Main() int {
 
 	logger := promslog.New(promlogConfig)
 
-	config, err := readConfig(*cfgFile, &defaultCollector, *logger)
+	loader := newConfigLoader(*cfgFile, &defaultCollector, *logger)
+	loader.timeoutOffset = time.Duration(*timeoutOffset * float64(time.Second))
+	config, err := loader.load()
 	if config == nil {
 		logger.Error("Error reading config", "file", *cfgFile, "reason", err)
 		return 1
 	}
-
-	if len(config.Exporter.Files) == 0 && len(config.Exporter.Trees) == 0 {
-		logger.Error("filestat_exporter requires a config file with patterns or trees or at least one argument file to match")
-		return 1
-	}
+	startup := config.Exporter
+	loader.startup = &startup
 
 	// adjust working directory globally
 	if *workingDir != defaultWorkingDir {
@@ -131,13 +142,21 @@ func Main() int {
 		logger.Info("Working directory", "path", path)
 	}
 
-	// create collector
-	collector := config.generateCollector(*logger)
-	if err := prometheus.Register(collector); err != nil {
+	// states of files kept across restarts
+	stateFile := config.Exporter.StateFile
+	if len(stateFile) != 0 {
+		loader.loadStates(stateFile)
+		interval := cmp.Or(config.Exporter.StateWriteInterval, defaultStateInterval)
+		logger.Info("Writing state file", "file", stateFile, "interval", interval)
+		go loader.saveStatesPeriodically(stateFile, interval)
+	}
+
+	// create collector - reloaded on SIGHUP
+	loader.apply(config)
+	if err := loader.register(prometheus.DefaultRegisterer); err != nil {
 		logger.Error("Could not register collector", "reason", err)
-	} else {
-		logger.Info("Collector ready to collect files", "nb_tree", len(collector.trees))
 	}
+	loader.watchSignals()
 
 	// setting up exporter
 	logger.Info("Starting file_status_exporter", "version", version.Info(), "build", version.BuildContext())
@@ -168,7 +187,9 @@ func Main() int {
 	} else {
 		logger.Info("Debug mode enables pprof endpoints on /debug/pprof/")
 	}
-	http.Handle(actualMetricsPath, promhttp.Handler())
+	http.Handle(actualMetricsPath, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, http.HandlerFunc(loader.serveMetrics)))
+	http.HandleFunc(reloadPath, loader.serveReload)
+	http.HandleFunc(probePath, loader.serveProbe)
 	if actualMetricsPath != "/" {
 		if err := SetLandingPage(actualMetricsPath, *debugMode); err != nil {
 			logger.Error("Could not set index page", "reason", err)
@@ -179,14 +200,37 @@ func Main() int {
 	// run exporter
 	(*webConfig.WebListenAddresses)[0] = config.Exporter.ListenAddress
 	server := &http.Server{}
-	if err := web.ListenAndServe(server, &webConfig, logger); err != nil {
+	stopped := loader.watchTermination(server, stateFile)
+	if err := web.ListenAndServe(server, &webConfig, logger); !errors.Is(err, http.ErrServerClosed) {
 		logger.Error("Listening error", "reason", err)
 		return 1
 	}
+	<-stopped
 
 	return 0
 }
 
+// shutdown server and write state file on SIGTERM or SIGINT - channel is closed when done
+func (l *configLoader) watchTermination(server *http.Server, stateFile string) <-chan struct{} {
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, syscall.SIGTERM, os.Interrupt)
+	stopped := make(chan struct{})
+	go func() {
+		sig := <-term
+		l.logger.Info("Stopping exporter", "signal", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			l.logger.Warn("Error stopping server", "reason", err)
+		}
+		if len(stateFile) != 0 {
+			l.saveStates(stateFile)
+		}
+		close(stopped)
+	}()
+	return stopped
+}
+
 // Setup index page which server as landing page
 func SetLandingPage(metricsPath string, debugMode bool) error {
 	extraCss := ""
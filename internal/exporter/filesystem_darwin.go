@@ -0,0 +1,34 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// capacity of filesystem from statfs
+func statFilesystem(dirPath string) (filesystemStat, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dirPath, &stat); err != nil {
+		return filesystemStat{}, err
+	}
+	return filesystemStat{
+		mountPoint:   unix.ByteSliceToString(stat.Mntonname[:]),
+		sizeBytes:    stat.Blocks * uint64(stat.Bsize),
+		availBytes:   stat.Bavail * uint64(stat.Bsize),
+		hasFilesFree: true,
+		filesFree:    stat.Ffree,
+	}, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// capacity of filesystem from statfs
+func statFilesystem(dirPath string) (filesystemStat, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dirPath, &stat); err != nil {
+		return filesystemStat{}, err
+	}
+	return filesystemStat{
+		mountPoint:   mountPoint(dirPath),
+		sizeBytes:    stat.Blocks * uint64(stat.Bsize),
+		availBytes:   stat.Bavail * uint64(stat.Bsize),
+		hasFilesFree: true,
+		filesFree:    stat.Ffree,
+	}, nil
+}
+
+// longest mount point of /proc/self/mountinfo containing directory - empty if not found
+func mountPoint(dirPath string) string {
+	realPath, err := filepath.EvalSymlinks(dirPath)
+	if err != nil {
+		return ""
+	}
+	if realPath, err = filepath.Abs(realPath); err != nil {
+		return ""
+	}
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	found := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mountPoint := unescapeMountPoint(fields[4])
+		if len(mountPoint) > len(found) && isPathInDir(realPath, mountPoint) {
+			found = mountPoint
+		}
+	}
+	return found
+}
+
+// true if path is directory or is inside it
+func isPathInDir(path string, dir string) bool {
+	return path == dir || dir == "/" || strings.HasPrefix(path, dir+"/")
+}
+
+// replace octal escapes of mountinfo such as \040 for space
+func unescapeMountPoint(mountPoint string) string {
+	if !strings.Contains(mountPoint, `\`) {
+		return mountPoint
+	}
+	var unescaped strings.Builder
+	for i := 0; i < len(mountPoint); i++ {
+		if mountPoint[i] == '\\' && i+3 < len(mountPoint) {
+			if value, err := strconv.ParseUint(mountPoint[i+1:i+4], 8, 8); err == nil {
+				unescaped.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		unescaped.WriteByte(mountPoint[i])
+	}
+	return unescaped.String()
+}
@@ -0,0 +1,49 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+)
+
+func TestUnescapeMountPoint_ShouldReplaceOctalEscapes(t *testing.T) {
+	for escaped, expected := range map[string]string{
+		"/mnt/data":         "/mnt/data",
+		`/mnt/my\040disk`:   "/mnt/my disk",
+		`/mnt/tab\011`:      "/mnt/tab\t",
+		`/mnt/not\escaped`:  `/mnt/not\escaped`,
+		`/mnt/truncated\04`: `/mnt/truncated\04`,
+	} {
+		if mountPoint := unescapeMountPoint(escaped); mountPoint != expected {
+			t.Errorf("Mount point %q unescaped as %q instead of %q", escaped, mountPoint, expected)
+		}
+	}
+}
+
+func TestStatFilesystem_ShouldFindMountPointOfDirectory(t *testing.T) {
+	stat, err := statFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to stat filesystem: %v", err)
+	}
+	if len(stat.mountPoint) == 0 || stat.mountPoint[0] != '/' {
+		t.Errorf("Mount point of filesystem is %q", stat.mountPoint)
+	}
+	if stat.sizeBytes == 0 || stat.availBytes > stat.sizeBytes {
+		t.Errorf("Capacity of filesystem is %d bytes with %d available", stat.sizeBytes, stat.availBytes)
+	}
+	if isPathInDir("/mnt/data2", "/mnt/data") || !isPathInDir("/mnt/data/file", "/mnt/data") {
+		t.Error("Path in directory not correctly detected")
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// capacity of volume of directory - free inodes are not available
+func statFilesystem(dirPath string) (filesystemStat, error) {
+	dirPathPtr, err := windows.UTF16PtrFromString(dirPath)
+	if err != nil {
+		return filesystemStat{}, err
+	}
+	var availBytes, sizeBytes, freeBytes uint64
+	if err := windows.GetDiskFreeSpaceEx(dirPathPtr, &availBytes, &sizeBytes, &freeBytes); err != nil {
+		return filesystemStat{}, err
+	}
+	volumePath := make([]uint16, windows.MAX_PATH+1)
+	mountPoint := ""
+	if err := windows.GetVolumePathName(dirPathPtr, &volumePath[0], uint32(len(volumePath))); err == nil {
+		mountPoint = windows.UTF16ToString(volumePath)
+	}
+	return filesystemStat{
+		mountPoint: mountPoint,
+		sizeBytes:  sizeBytes,
+		availBytes: availBytes,
+	}, nil
+}
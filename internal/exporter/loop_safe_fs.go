@@ -0,0 +1,105 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Identity of a directory
+type dirIdentity struct {
+	device uint64
+	inode  uint64
+}
+
+// File system of a glob which doesn't read directories looping on an ancestor
+//
+// Directories are identified by device and inode, systems without file identity
+// are not protected. Directories are not read anymore once the context is done
+// and directories at maximum depth are empty. It is used by a single glob at a time.
+type loopSafeFS struct {
+	ctx        context.Context
+	fsys       fs.FS
+	identities map[string]*dirIdentity
+	onLoop     func(name string)
+	// 0 is unlimited
+	maxDepth int
+}
+
+func newLoopSafeFS(ctx context.Context, root string, onLoop func(name string)) *loopSafeFS {
+	return &loopSafeFS{
+		ctx:        ctx,
+		fsys:       os.DirFS(root),
+		identities: make(map[string]*dirIdentity),
+		onLoop:     onLoop,
+	}
+}
+
+// Open implements fs.FS
+func (f *loopSafeFS) Open(name string) (fs.File, error) {
+	return f.fsys.Open(name)
+}
+
+// Stat implements fs.StatFS
+func (f *loopSafeFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, name)
+}
+
+// ReadDir implements fs.ReadDirFS - a directory looping on an ancestor is empty
+func (f *loopSafeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.maxDepth != 0 && depth(name) >= f.maxDepth {
+		return []fs.DirEntry{}, nil
+	}
+	if identity := f.identity(name); identity != nil {
+		for parent := name; parent != "."; {
+			parent = path.Dir(parent)
+			if parentIdentity := f.identity(parent); parentIdentity != nil && *parentIdentity == *identity {
+				f.onLoop(name)
+				return []fs.DirEntry{}, nil
+			}
+		}
+	}
+	return fs.ReadDir(f.fsys, name)
+}
+
+// identity of directory following links - nil if not available
+func (f *loopSafeFS) identity(name string) *dirIdentity {
+	if identity, found := f.identities[name]; found {
+		return identity
+	}
+	var identity *dirIdentity
+	if fileinfo, err := fs.Stat(f.fsys, name); err == nil {
+		if device, inode, ok := fileIdentity(fileinfo); ok {
+			identity = &dirIdentity{device: device, inode: inode}
+		}
+	}
+	f.identities[name] = identity
+	return identity
+}
+
+// number of path elements of name - 0 for root
+func depth(name string) int {
+	if name == "." {
+		return 0
+	}
+	return strings.Count(name, "/") + 1
+}
@@ -0,0 +1,75 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+func TestLoopSafeFS_ShouldNotFollowLinkToAncestor(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a", "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a", "b", "file.log"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..", filepath.Join(root, "a", "b", "loop")); err != nil {
+		t.Skip("Symbolic links not supported:", err)
+	}
+
+	loops := []string{}
+	fsys := newLoopSafeFS(t.Context(), root, func(name string) { loops = append(loops, name) })
+	matches, err := doublestar.Glob(fsys, "**/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(matches, []string{"a/b/file.log"}) {
+		t.Errorf("Matches are %q", matches)
+	}
+	if !slices.Contains(loops, "a/b/loop") {
+		t.Errorf("Loops detected at %q", loops)
+	}
+}
+
+func TestLoopSafeFS_ShouldNotReadDirectoriesAtMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a", "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"root.log", "a/a.log", "a/b/b.log"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsys := newLoopSafeFS(t.Context(), root, func(name string) {})
+	fsys.maxDepth = 2
+	matches, err := doublestar.Glob(fsys, "**/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(matches)
+	if !slices.Equal(matches, []string{"a/a.log", "root.log"}) {
+		t.Errorf("Matches are %q", matches)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+)
+
+// Names of ids read from a local file in passwd format
+type idNames struct {
+	file    string
+	modTime time.Time
+	names   map[string]string
+}
+
+// read file again if it changed
+func (n *idNames) refresh() {
+	info, err := os.Stat(n.file)
+	if err != nil {
+		n.modTime = time.Time{}
+		n.names = nil
+		return
+	}
+	if n.names != nil && info.ModTime().Equal(n.modTime) {
+		return
+	}
+	n.modTime = info.ModTime()
+	n.names = readIDNames(n.file)
+}
+
+// read names of ids from file - first definition of an id is used
+func readIDNames(file string) map[string]string {
+	names := make(map[string]string)
+	r, err := os.Open(file)
+	if err != nil {
+		return names
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == '#' || line[0] == '+' || line[0] == '-' {
+			continue
+		}
+		// name:password:id:...
+		fields := strings.SplitN(line, ":", 4)
+		if len(fields) < 3 {
+			continue
+		}
+		if _, found := names[fields[2]]; !found {
+			names[fields[2]] = fields[0]
+		}
+	}
+	return names
+}
+
+// Cache of user and group names from local files
+type ownerNames struct {
+	mutex  sync.RWMutex
+	users  idNames
+	groups idNames
+}
+
+func newOwnerNames() *ownerNames {
+	return &ownerNames{
+		users:  idNames{file: passwdFile},
+		groups: idNames{file: groupFile},
+	}
+}
+
+// read local files again if they changed
+func (o *ownerNames) refresh() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.users.refresh()
+	o.groups.refresh()
+}
+
+// name of user - empty if unknown
+func (o *ownerNames) userName(uid string) string {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.users.names[uid]
+}
+
+// name of group - empty if unknown
+func (o *ownerNames) groupName(gid string) string {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.groups.names[gid]
+}
@@ -0,0 +1,41 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadIDNames_ShouldUseFirstDefinitionOfId(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "passwd")
+	content := "# comment\nroot:x:0:0:root:/root:/bin/bash\n+nis\nadmin:x:0:0::/:/bin/sh\nnobody:x:65534:65534::/:/bin/false\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	names := readIDNames(file)
+
+	if names["0"] != "root" {
+		t.Error("Wrong name of id 0:", names["0"])
+	}
+	if names["65534"] != "nobody" {
+		t.Error("Wrong name of id 65534:", names["65534"])
+	}
+	if len(names) != 2 {
+		t.Error("Wrong number of names:", names)
+	}
+}
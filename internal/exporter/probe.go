@@ -0,0 +1,84 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	probeSuccessOpts = prometheus.GaugeOpts{
+		Name: "probe_success",
+		Help: "Whether the probe target could be found",
+	}
+	probeDurationSecondsOpts = prometheus.GaugeOpts{
+		Name: "probe_duration_seconds",
+		Help: "Duration of the probe in seconds",
+	}
+)
+
+// collect metrics of module applied on target - similar to blackbox exporter
+func (l *configLoader) serveProbe(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	moduleName := params.Get("module")
+	if len(moduleName) == 0 {
+		http.Error(w, "Module parameter is missing", http.StatusBadRequest)
+		return
+	}
+	target := params.Get("target")
+	if len(target) == 0 {
+		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	config, _ := l.current.get()
+	collector, err := config.generateProbeCollector(l.logger, moduleName, target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	collector.useContentCache(l.contentCache)
+
+	probeSuccess := prometheus.NewGauge(probeSuccessOpts)
+	probeDurationSeconds := prometheus.NewGauge(probeDurationSecondsOpts)
+	if _, err := os.Stat(target); err == nil {
+		probeSuccess.Set(1)
+	} else {
+		l.logger.Debug("Probe target not found", "module", moduleName, "target", target, "reason", err)
+	}
+
+	// collect files metrics first in order to measure probe duration
+	ctx, cancel := scrapeContext(r, config.Exporter.ScrapeTimeout, l.timeoutOffset)
+	defer cancel()
+	start := time.Now()
+	filesRegistry := prometheus.NewRegistry()
+	filesRegistry.MustRegister(&scrapeCollector{ctx: ctx, collector: collector})
+	metricFamilies, err := filesRegistry.Gather()
+	probeDurationSeconds.Set(time.Since(start).Seconds())
+
+	probeRegistry := prometheus.NewRegistry()
+	probeRegistry.MustRegister(probeSuccess, probeDurationSeconds)
+	gatherers := prometheus.Gatherers{
+		prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return metricFamilies, err }),
+		probeRegistry,
+	}
+	promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
@@ -0,0 +1,134 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// loader of config with module collecting logs of target
+func newProbeLoader(module *treeConfig) *configLoader {
+	module.Files = []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}
+	cfg := configContent{}
+	cfg.Exporter.Modules = map[string]*treeConfig{"logs": module}
+	loader := newConfigLoader("none", &treeConfig{}, *slog.Default())
+	loader.current.set(&cfg, nil)
+	return loader
+}
+
+// probe request with parameters
+func probe(loader *configLoader, params url.Values) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	loader.serveProbe(w, httptest.NewRequest("GET", "/probe?"+params.Encode(), nil))
+	return w
+}
+
+func TestServeProbe_ShouldNotApplyTemplateOnTarget(t *testing.T) {
+	target := filepath.Join(t.TempDir(), `{{ "templated" }}`)
+	if err := os.Mkdir(target, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "app.log"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := configContent{}
+	cfg.Exporter.Modules = map[string]*treeConfig{"logs": {Files: []*collectorConfig{{GlobPatternPath: []string{"*.log"}}}}}
+	loader := newConfigLoader("none", &treeConfig{}, *slog.Default())
+	loader.current.set(&cfg, nil)
+
+	w := httptest.NewRecorder()
+	query := url.Values{"module": {"logs"}, "target": {target}}
+	loader.serveProbe(w, httptest.NewRequest("GET", "/probe?"+query.Encode(), nil))
+
+	body, _ := io.ReadAll(w.Result().Body)
+	if !strings.Contains(string(body), "probe_success 1") {
+		t.Error("Target containing template delimiters not found:", string(body))
+	}
+	if !strings.Contains(string(body), `file_stat_size_bytes{path="app.log"}`) {
+		t.Error("Files of target containing template delimiters not collected:", string(body))
+	}
+}
+
+func TestServeProbe_ShouldRejectInvalidParameters(t *testing.T) {
+	target := t.TempDir()
+	loader := newProbeLoader(&treeConfig{})
+
+	for _, params := range []url.Values{
+		{"target": {target}},
+		{"module": {"logs"}},
+		{"module": {"unknown"}, "target": {target}},
+	} {
+		if w := probe(loader, params); w.Code != http.StatusBadRequest {
+			t.Errorf("Probe with %v returned status %d instead of %d", params, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestServeProbe_ShouldFailWhenTargetNotFound(t *testing.T) {
+	loader := newProbeLoader(&treeConfig{})
+
+	w := probe(loader, url.Values{"module": {"logs"}, "target": {filepath.Join(t.TempDir(), "missing")}})
+
+	if body := w.Body.String(); !strings.Contains(body, "probe_success 0") {
+		t.Error("Probe of missing target is successful:", body)
+	}
+}
+
+func TestServeProbe_ShouldRejectTargetOutsideAllowedRoots(t *testing.T) {
+	allowedRoot := t.TempDir()
+	loader := newProbeLoader(&treeConfig{AllowedRoots: []string{allowedRoot}})
+
+	for target, status := range map[string]int{
+		allowedRoot:                            http.StatusOK,
+		filepath.Join(allowedRoot, "app"):      http.StatusOK,
+		filepath.Join(allowedRoot, "..", "up"): http.StatusBadRequest,
+		t.TempDir():                            http.StatusBadRequest,
+	} {
+		if w := probe(loader, url.Values{"module": {"logs"}, "target": {target}}); w.Code != status {
+			t.Errorf("Probe of %q returned status %d instead of %d", target, w.Code, status)
+		}
+	}
+}
+
+func TestServeProbe_ShouldUseContentCache(t *testing.T) {
+	target := t.TempDir()
+	createFiles(t, target, map[string]int{"app.log": 10})
+	enabled := true
+	module := &treeConfig{}
+	loader := newProbeLoader(module)
+	module.Files[0].EnableCRC32Metric = &enabled
+	loader.contentCache.resize(10)
+
+	probe(loader, url.Values{"module": {"logs"}, "target": {target}})
+	probe(loader, url.Values{"module": {"logs"}, "target": {target}})
+
+	hits := &dto.Metric{}
+	if err := loader.contentCache.hits.Write(hits); err != nil {
+		t.Fatal(err)
+	}
+	if hits.GetCounter().GetValue() != 1 {
+		t.Errorf("Second probe has %v content cache hits instead of 1", hits.GetCounter().GetValue())
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const exporterNamespace = "filestat"
+
+var (
+	configLastReloadSuccessfulOpts = prometheus.GaugeOpts{
+		Namespace: exporterNamespace,
+		Subsystem: "config",
+		Name:      "last_reload_successful",
+		Help:      "Whether the last configuration reload attempt was successful",
+	}
+	configLastReloadSuccessTimeOpts = prometheus.GaugeOpts{
+		Namespace: exporterNamespace,
+		Subsystem: "config",
+		Name:      "last_reload_success_timestamp_seconds",
+		Help:      "Timestamp of the last successful configuration reload",
+	}
+)
+
+// Config and collector of the last valid config
+type reloadableCollector struct {
+	mutex     sync.RWMutex
+	config    *configContent
+	collector *filesCollector
+}
+
+// swap config and collector
+func (r *reloadableCollector) set(config *configContent, collector *filesCollector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.config = config
+	r.collector = collector
+}
+
+// get current config and collector
+func (r *reloadableCollector) get() (*configContent, *filesCollector) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.config, r.collector
+}
+
+// Loader of config file generating collectors
+type configLoader struct {
+	cfgFile          string
+	defaultCollector *treeConfig
+	logger           slog.Logger
+
+	// serialize reloads
+	mutex   sync.Mutex
+	current reloadableCollector
+
+	lastReloadSuccessful  prometheus.Gauge
+	lastReloadSuccessTime prometheus.Gauge
+
+	// subtracted from scrape timeout of Prometheus
+	timeoutOffset time.Duration
+	// config of exporter read at startup - before override by parameters
+	startup *configExporter
+
+	// kept across reloads
+	contentCache *contentCache
+	scrapeStats  *scrapeStats
+	changeStates *changeStates
+}
+
+func newConfigLoader(cfgFile string, defaultCollector *treeConfig, logger slog.Logger) *configLoader {
+	// config file is reloaded after the working directory is changed
+	if cfgFile != "none" {
+		if absCfgFile, err := filepath.Abs(cfgFile); err == nil {
+			cfgFile = absCfgFile
+		}
+	}
+	return &configLoader{
+		cfgFile:               cfgFile,
+		defaultCollector:      defaultCollector,
+		logger:                logger,
+		lastReloadSuccessful:  prometheus.NewGauge(configLastReloadSuccessfulOpts),
+		lastReloadSuccessTime: prometheus.NewGauge(configLastReloadSuccessTimeOpts),
+		contentCache:          newContentCache(),
+		scrapeStats:           newScrapeStats(),
+		changeStates:          newChangeStates(),
+	}
+}
+
+// read and validate config file
+func (l *configLoader) load() (*configContent, error) {
+	config, err := readConfig(l.cfgFile, l.defaultCollector, l.logger)
+	if config == nil {
+		return nil, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// generate collector from config and make it current
+func (l *configLoader) apply(config *configContent) *filesCollector {
+	collector := config.generateCollector(l.logger)
+	l.contentCache.resize(config.Exporter.ContentCacheMaxEntries)
+	collector.useContentCache(l.contentCache)
+	collector.useScrapeStats(l.scrapeStats)
+	collector.useChangeStates(l.changeStates)
+	l.changeStates.pruneTrees(collector.trees)
+	l.current.set(config, collector)
+	l.lastReloadSuccessful.Set(1)
+	l.lastReloadSuccessTime.SetToCurrentTime()
+	l.logger.Info("Collector ready to collect files", "nb_tree", len(collector.trees))
+	return collector
+}
+
+// reload config file - current collector is kept if config is invalid
+func (l *configLoader) reload() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.logger.Info("Reloading config", "file", l.cfgFile)
+	config, err := l.load()
+	if err != nil {
+		l.logger.Error("Error reloading config", "file", l.cfgFile, "reason", err)
+		l.lastReloadSuccessful.Set(0)
+		return err
+	}
+	if l.startup != nil {
+		warnStartupOnlyChanges(l.logger, l.startup, &config.Exporter)
+	}
+	l.apply(config)
+	return nil
+}
+
+// warn that changes of parameters only read at startup are ignored until restart
+func warnStartupOnlyChanges(logger slog.Logger, startup *configExporter, config *configExporter) {
+	for _, param := range []struct {
+		name    string
+		changed bool
+	}{
+		{"working_directory", config.WorkingDirectory != startup.WorkingDirectory},
+		{"listen_address", config.ListenAddress != startup.ListenAddress},
+		{"metrics_path", config.MetricsPath != startup.MetricsPath},
+		{"state_file", config.StateFile != startup.StateFile},
+		{"state_write_interval", config.StateWriteInterval != startup.StateWriteInterval},
+	} {
+		if param.changed {
+			logger.Warn("Change of parameter ignored until restart", "parameter", param.name)
+		}
+	}
+}
+
+// register collector and reload metrics
+func (l *configLoader) register(registerer prometheus.Registerer) error {
+	collectors := []prometheus.Collector{
+		l.lastReloadSuccessful,
+		l.lastReloadSuccessTime,
+		l.contentCache.hits,
+		l.contentCache.misses,
+	}
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect metrics of exporter and of files of current collector until scrape timeout
+//
+// Files metrics are collected first so that metrics of exporter include the current scrape.
+func (l *configLoader) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	config, collector := l.current.get()
+	ctx, cancel := scrapeContext(r, config.Exporter.ScrapeTimeout, l.timeoutOffset)
+	defer cancel()
+
+	filesRegistry := prometheus.NewRegistry()
+	filesRegistry.MustRegister(&scrapeCollector{ctx: ctx, collector: collector})
+	metricFamilies, err := filesRegistry.Gather()
+	gatherers := prometheus.Gatherers{
+		prometheus.DefaultGatherer,
+		prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return metricFamilies, err }),
+	}
+	promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// reload config on SIGHUP
+func (l *configLoader) watchSignals() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			l.reload()
+		}
+	}()
+}
+
+// reload config on POST request
+func (l *configLoader) serveReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "This endpoint requires a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := l.reload(); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to reload config: %s", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// write config file and create loader reading it
+func newTestLoader(t *testing.T, content string) (*configLoader, string) {
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgFile, content)
+	root := t.TempDir()
+	disabled := false
+	defaultCollector := treeConfig{TreeRoot: &root}
+	defaultCollector.EnableCRC32Metric = &disabled
+	defaultCollector.EnableNbLineMetric = &disabled
+	return newConfigLoader(cfgFile, &defaultCollector, *slog.New(slog.NewTextHandler(io.Discard, nil))), cfgFile
+}
+
+func writeConfig(t *testing.T, cfgFile string, content string) {
+	if err := os.WriteFile(cfgFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// config file of tree with given name
+func treeConfigFile(treeName string) string {
+	return "exporter:\n  tree_name: " + treeName + "\n  files:\n    - patterns: ['*.log']\n"
+}
+
+// current value of gauge
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	metric := &dto.Metric{}
+	if err := gauge.Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetGauge().GetValue()
+}
+
+// name of tree of current config
+func currentTreeName(loader *configLoader) string {
+	config, _ := loader.current.get()
+	if config.Exporter.TreeName == nil {
+		return ""
+	}
+	return *config.Exporter.TreeName
+}
+
+func TestReload_ShouldKeepCurrentCollectorWhenConfigInvalid(t *testing.T) {
+	loader, cfgFile := newTestLoader(t, treeConfigFile("first"))
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	successTime := gaugeValue(t, loader.lastReloadSuccessTime)
+	if gaugeValue(t, loader.lastReloadSuccessful) != 1 {
+		t.Error("Reload of valid config is not successful")
+	}
+
+	writeConfig(t, cfgFile, "exporter:\n  unknown_parameter: 1\n")
+	if err := loader.reload(); err == nil {
+		t.Error("Reload of invalid config did not fail")
+	}
+	if gaugeValue(t, loader.lastReloadSuccessful) != 0 {
+		t.Error("Reload of invalid config is successful")
+	}
+	if gaugeValue(t, loader.lastReloadSuccessTime) != successTime {
+		t.Error("Success timestamp changed by reload of invalid config")
+	}
+	if name := currentTreeName(loader); name != "first" {
+		t.Errorf("Current config has tree %q instead of previous config", name)
+	}
+	w := httptest.NewRecorder()
+	loader.serveMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	if body, _ := io.ReadAll(w.Result().Body); !strings.Contains(string(body), `tree="first"`) {
+		t.Error("Metrics of previous collector not served:", string(body))
+	}
+
+	writeConfig(t, cfgFile, treeConfigFile("second"))
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if gaugeValue(t, loader.lastReloadSuccessful) != 1 {
+		t.Error("Reload of valid config after invalid config is not successful")
+	}
+	if gaugeValue(t, loader.lastReloadSuccessTime) <= successTime {
+		t.Error("Success timestamp did not move forward on successful reload")
+	}
+	if name := currentTreeName(loader); name != "second" {
+		t.Errorf("Current config has tree %q instead of reloaded config", name)
+	}
+}
+
+func TestServeMetrics_ShouldCountContentCacheOfSameScrape(t *testing.T) {
+	loader, _ := newTestLoader(t, "exporter:\n  content_cache_max_entries: 10\n  files:\n    - patterns: ['*.log']\n      enable_crc32_metric: true\n")
+	createFiles(t, *loader.defaultCollector.TreeRoot, map[string]int{"app.log": 10})
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.register(prometheus.DefaultRegisterer); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		prometheus.DefaultRegisterer.Unregister(loader.contentCache.hits)
+		prometheus.DefaultRegisterer.Unregister(loader.contentCache.misses)
+		prometheus.DefaultRegisterer.Unregister(loader.lastReloadSuccessful)
+		prometheus.DefaultRegisterer.Unregister(loader.lastReloadSuccessTime)
+	})
+
+	for scrape, expected := range []string{
+		"filestat_content_cache_misses_total 1",
+		"filestat_content_cache_hits_total 1",
+	} {
+		w := httptest.NewRecorder()
+		loader.serveMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+		if body, _ := io.ReadAll(w.Result().Body); !strings.Contains(string(body), expected) {
+			t.Errorf("Scrape %d does not report %q: %s", scrape, expected, body)
+		}
+	}
+}
+
+func TestApply_ShouldPruneChangeStatesOfRemovedTrees(t *testing.T) {
+	loader, _ := newTestLoader(t, treeConfigFile("first"))
+	info := changedFile(time.Unix(1000, 0), 100)
+	loader.changeStates.update(changeKey{tree: "first", path: "app.log"}, fileObservation{fileinfo: info})
+	loader.changeStates.update(changeKey{tree: "removed", path: "app.log"}, fileObservation{fileinfo: info})
+
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := loader.changeStates.states[changeKey{tree: "first", path: "app.log"}]; !found {
+		t.Error("State of file of existing tree was pruned")
+	}
+	if _, found := loader.changeStates.states[changeKey{tree: "removed", path: "app.log"}]; found {
+		t.Error("State of file of removed tree was not pruned")
+	}
+}
+
+func TestServeReload_ShouldRequirePost(t *testing.T) {
+	loader, _ := newTestLoader(t, treeConfigFile("first"))
+
+	w := httptest.NewRecorder()
+	loader.serveReload(w, httptest.NewRequest("GET", "/-/reload", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET request returned status %d instead of %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := w.Header().Get("Allow"); allow != http.MethodPost {
+		t.Errorf("Allow header is %q instead of POST", allow)
+	}
+}
+
+func TestServeReload_ShouldReturnErrorOfInvalidConfig(t *testing.T) {
+	loader, cfgFile := newTestLoader(t, treeConfigFile("first"))
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, cfgFile, "exporter:\n  unknown_parameter: 1\n")
+
+	w := httptest.NewRecorder()
+	loader.serveReload(w, httptest.NewRequest("POST", "/-/reload", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Reload of invalid config returned status %d instead of %d", w.Code, http.StatusInternalServerError)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "unknown_parameter") {
+		t.Error("Error of config not returned:", body)
+	}
+}
+
+func TestWarnStartupOnlyChanges_ShouldLogChangedParameters(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	startup := configExporter{ListenAddress: ":9943", MetricsPath: "/metrics"}
+	reloaded := configExporter{ListenAddress: ":9944", MetricsPath: "/metrics"}
+
+	warnStartupOnlyChanges(*logger, &startup, &reloaded)
+
+	if !strings.Contains(logs.String(), "parameter=listen_address") {
+		t.Error("Change of listen address not logged:", logs.String())
+	}
+	if strings.Contains(logs.String(), "parameter=metrics_path") {
+		t.Error("Unchanged metrics path logged:", logs.String())
+	}
+}
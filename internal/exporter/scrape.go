@@ -0,0 +1,69 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// header of Prometheus with the timeout of scrape
+const scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+// Collector of files metrics during a scrape - collection stops at end of context
+//
+// The collector is unchecked because the described metrics change with the config.
+type scrapeCollector struct {
+	ctx       context.Context
+	collector *filesCollector
+}
+
+// Describe implements the prometheus.Collector interface.
+func (s *scrapeCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+// Collect implements the prometheus.Collector interface.
+func (s *scrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	if s.collector != nil {
+		s.collector.CollectContext(s.ctx, ch)
+	}
+}
+
+// context of scrape request with deadline of Prometheus timeout header or of configured timeout
+//
+// The shortest timeout is used, no deadline is set if none is defined. The offset is subtracted
+// from the timeout of Prometheus so that metrics are sent before Prometheus gives up.
+func scrapeContext(r *http.Request, scrapeTimeout time.Duration, offset time.Duration) (context.Context, context.CancelFunc) {
+	timeout := scrapeTimeout
+	if header := r.Header.Get(scrapeTimeoutHeader); len(header) != 0 {
+		if seconds, err := strconv.ParseFloat(header, 64); err == nil && seconds > 0 {
+			headerTimeout := time.Duration(seconds * float64(time.Second))
+			if headerTimeout > offset {
+				headerTimeout -= offset
+			}
+			if timeout == 0 || headerTimeout < timeout {
+				timeout = headerTimeout
+			}
+		}
+	}
+	if timeout == 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
@@ -0,0 +1,100 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stages of collection of a tree where errors are counted
+const (
+	scrapeStageTemplate = "template"
+	scrapeStageGlob     = "glob"
+	scrapeStageStat     = "stat"
+	scrapeStageRead     = "read"
+)
+
+var scrapeStages = []string{scrapeStageTemplate, scrapeStageGlob, scrapeStageStat, scrapeStageRead}
+
+var (
+	scrapeDurationSecondsOpts = prometheus.Opts{
+		Namespace: exporterNamespace,
+		Subsystem: "scrape",
+		Name:      "duration_seconds",
+		Help:      "Duration of last collection of tree in seconds",
+	}
+	scrapeTruncatedOpts = prometheus.Opts{
+		Namespace: exporterNamespace,
+		Subsystem: "scrape",
+		Name:      "truncated",
+		Help:      "Whether last collection of tree stopped at deadline of scrape",
+	}
+	scrapeErrorsOpts = prometheus.Opts{
+		Namespace: exporterNamespace,
+		Subsystem: "scrape",
+		Name:      "errors_total",
+		Help:      "Number of errors while collecting tree by stage",
+	}
+	bytesReadOpts = prometheus.Opts{
+		Namespace: exporterNamespace,
+		Name:      "bytes_read_total",
+		Help:      "Number of bytes read from content of files of tree",
+	}
+	filesScannedOpts = prometheus.Opts{
+		Namespace: exporterNamespace,
+		Name:      "files_scanned_total",
+		Help:      "Number of files of tree whose info was read",
+	}
+)
+
+// Counters of collections of a tree - updated by parallel workers
+type treeScrapeStats struct {
+	errors       map[string]*atomic.Uint64
+	bytesRead    atomic.Uint64
+	filesScanned atomic.Uint64
+}
+
+// count an error of stage
+func (stats *treeScrapeStats) addError(stage string) {
+	stats.errors[stage].Add(1)
+}
+
+// Counters of collections of trees - kept across reloads
+type scrapeStats struct {
+	mutex sync.Mutex
+	trees map[string]*treeScrapeStats
+}
+
+func newScrapeStats() *scrapeStats {
+	return &scrapeStats{trees: make(map[string]*treeScrapeStats)}
+}
+
+// get counters of tree - created on first collection of tree
+func (s *scrapeStats) tree(name string) *treeScrapeStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats, found := s.trees[name]
+	if !found {
+		stats = &treeScrapeStats{errors: make(map[string]*atomic.Uint64)}
+		for _, stage := range scrapeStages {
+			stats.errors[stage] = &atomic.Uint64{}
+		}
+		s.trees[name] = stats
+	}
+	return stats
+}
@@ -0,0 +1,34 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+)
+
+func TestScrapeStats_ShouldKeepCountersOfTree(t *testing.T) {
+	stats := newScrapeStats()
+	stats.tree("tree1").addError(scrapeStageGlob)
+	stats.tree("tree1").addError(scrapeStageGlob)
+	stats.tree("tree2").addError(scrapeStageRead)
+
+	tree1 := stats.tree("tree1")
+	if errors := tree1.errors[scrapeStageGlob].Load(); errors != 2 {
+		t.Errorf("Tree has %d glob errors instead of 2", errors)
+	}
+	if errors := tree1.errors[scrapeStageRead].Load(); errors != 0 {
+		t.Errorf("Tree has %d read errors of other tree", errors)
+	}
+}
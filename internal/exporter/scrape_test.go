@@ -0,0 +1,71 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScrapeContext_ShouldUseShortestTimeout(t *testing.T) {
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set(scrapeTimeoutHeader, "0.5")
+
+	for scrapeTimeout, expected := range map[time.Duration]time.Duration{
+		0:                      500 * time.Millisecond,
+		100 * time.Millisecond: 100 * time.Millisecond,
+		time.Minute:            500 * time.Millisecond,
+	} {
+		start := time.Now()
+		ctx, cancel := scrapeContext(r, scrapeTimeout, 0)
+		end := time.Now()
+		deadline, ok := ctx.Deadline()
+		cancel()
+		if !ok || deadline.Before(start.Add(expected)) || deadline.After(end.Add(expected)) {
+			t.Errorf("Deadline of scrape with timeout %s is %s instead of %s", scrapeTimeout, deadline.Sub(start), expected)
+		}
+	}
+}
+
+func TestScrapeContext_ShouldSubtractOffsetFromPrometheusTimeout(t *testing.T) {
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set(scrapeTimeoutHeader, "10")
+
+	for scrapeTimeout, expected := range map[time.Duration]time.Duration{
+		0:               9500 * time.Millisecond,
+		5 * time.Second: 5 * time.Second,
+	} {
+		start := time.Now()
+		ctx, cancel := scrapeContext(r, scrapeTimeout, 500*time.Millisecond)
+		end := time.Now()
+		deadline, ok := ctx.Deadline()
+		cancel()
+		if !ok || deadline.Before(start.Add(expected)) || deadline.After(end.Add(expected)) {
+			t.Errorf("Deadline of scrape with timeout %s is %s instead of %s", scrapeTimeout, deadline.Sub(start), expected)
+		}
+	}
+}
+
+func TestScrapeContext_ShouldNotSetDeadlineWithoutTimeout(t *testing.T) {
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set(scrapeTimeoutHeader, "invalid")
+
+	ctx, cancel := scrapeContext(r, 0, 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Deadline set without timeout")
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// access and change time of file
+func statTimes(stat *syscall.Stat_t) (accessTime time.Time, changeTime time.Time) {
+	return time.Unix(stat.Atim.Unix()), time.Unix(stat.Ctim.Unix())
+}
+
+// creation time of file from statx() - zero if not supported by filesystem
+func birthTime(realFilePath string, stat *syscall.Stat_t) time.Time {
+	var statx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, realFilePath, unix.AT_STATX_SYNC_AS_STAT, unix.STATX_BTIME, &statx); err != nil {
+		return time.Time{}
+	}
+	if statx.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}
+	}
+	return time.Unix(statx.Btime.Sec, int64(statx.Btime.Nsec))
+}
@@ -0,0 +1,36 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix && !windows
+
+package exporter
+
+import (
+	"os"
+)
+
+// device and inode of file - not available
+func fileIdentity(fileinfo os.FileInfo) (device uint64, inode uint64, ok bool) {
+	return 0, 0, false
+}
+
+// owner user and group ids of file - not available
+func fileOwner(fileinfo os.FileInfo) (uid string, gid string, ok bool) {
+	return "", "", false
+}
+
+// statistics of file - not available
+func extendedStat(realFilePath string, fileinfo os.FileInfo, withBirthTime bool) fileStatExt {
+	return fileStatExt{}
+}
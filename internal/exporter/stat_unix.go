@@ -0,0 +1,60 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package exporter
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// device and inode of file
+func fileIdentity(fileinfo os.FileInfo) (device uint64, inode uint64, ok bool) {
+	stat, ok := fileinfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(stat.Dev), uint64(stat.Ino), true
+}
+
+// owner user and group ids of file
+func fileOwner(fileinfo os.FileInfo) (uid string, gid string, ok bool) {
+	stat, ok := fileinfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", false
+	}
+	return strconv.FormatUint(uint64(stat.Uid), 10), strconv.FormatUint(uint64(stat.Gid), 10), true
+}
+
+// statistics of file from stat() - birth time may need another system call
+func extendedStat(realFilePath string, fileinfo os.FileInfo, withBirthTime bool) fileStatExt {
+	stat, ok := fileinfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileStatExt{}
+	}
+	ext := fileStatExt{
+		hasNlink:          true,
+		nlink:             uint64(stat.Nlink),
+		hasAllocatedBytes: true,
+		allocatedBytes:    uint64(stat.Blocks) * 512,
+	}
+	ext.accessTime, ext.changeTime = statTimes(stat)
+	if withBirthTime {
+		ext.birthTime = birthTime(realFilePath, stat)
+	}
+	return ext
+}
@@ -0,0 +1,201 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// version of format of state file - older versions are migrated when loaded
+const stateFileVersion = 1
+
+// Content of state file
+type stateFileContent struct {
+	Version int              `json:"version"`
+	Files   []stateFileEntry `json:"files"`
+}
+
+// State of a file in state file
+type stateFileEntry struct {
+	Tree           string  `json:"tree,omitempty"`
+	Path           string  `json:"path"`
+	ModTimeNano    int64   `json:"mod_time_ns"`
+	Size           int64   `json:"size"`
+	Device         *uint64 `json:"device,omitempty"`
+	Inode          *uint64 `json:"inode,omitempty"`
+	Digest         *uint64 `json:"digest,omitempty"`
+	FirstSeenNano  int64   `json:"first_seen_ns"`
+	Modifications  uint64  `json:"modifications"`
+	ContentChanges uint64  `json:"content_changes"`
+	Truncations    uint64  `json:"truncations"`
+	Replacements   uint64  `json:"replacements"`
+}
+
+// copy of states in format of state file
+func (s *changeStates) toStateFile() stateFileContent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content := stateFileContent{Version: stateFileVersion, Files: make([]stateFileEntry, 0, len(s.states))}
+	for key, state := range s.states {
+		entry := stateFileEntry{
+			Tree:           key.tree,
+			Path:           key.path,
+			ModTimeNano:    state.modTimeNano,
+			Size:           state.size,
+			FirstSeenNano:  state.firstSeenNano,
+			Modifications:  state.modifications,
+			ContentChanges: state.contentChanges,
+			Truncations:    state.truncations,
+			Replacements:   state.replacements,
+		}
+		if state.hasIdentity {
+			device, inode := state.device, state.inode
+			entry.Device, entry.Inode = &device, &inode
+		}
+		if state.hasDigest {
+			digest := state.digest
+			entry.Digest = &digest
+		}
+		content.Files = append(content.Files, entry)
+	}
+	return content
+}
+
+// replace states with those of state file
+func (s *changeStates) fromStateFile(content stateFileContent) {
+	states := make(map[changeKey]*fileChangeState, len(content.Files))
+	for _, entry := range content.Files {
+		state := &fileChangeState{
+			modTimeNano:    entry.ModTimeNano,
+			size:           entry.Size,
+			firstSeenNano:  entry.FirstSeenNano,
+			modifications:  entry.Modifications,
+			contentChanges: entry.ContentChanges,
+			truncations:    entry.Truncations,
+			replacements:   entry.Replacements,
+		}
+		if entry.Device != nil && entry.Inode != nil {
+			state.hasIdentity = true
+			state.device, state.inode = *entry.Device, *entry.Inode
+		}
+		if entry.Digest != nil {
+			state.hasDigest = true
+			state.digest = *entry.Digest
+		}
+		states[changeKey{tree: entry.Tree, path: entry.Path}] = state
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.states = states
+}
+
+// State file of a version which cannot be migrated
+type stateFileVersionError struct {
+	version int
+}
+
+func (e *stateFileVersionError) Error() string {
+	return fmt.Sprintf("unsupported state file version %d", e.version)
+}
+
+// migrate content of state file of older version to current version
+func migrateStateFile(content *stateFileContent) error {
+	switch content.Version {
+	case stateFileVersion:
+		return nil
+	default:
+		return &stateFileVersionError{version: content.Version}
+	}
+}
+
+// read states from state file - missing file is not an error
+func (s *changeStates) load(stateFile string) error {
+	data, err := os.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var content stateFileContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return fmt.Errorf("invalid state file: %w", err)
+	}
+	if err := migrateStateFile(&content); err != nil {
+		return err
+	}
+	s.fromStateFile(content)
+	return nil
+}
+
+// read states from state file at startup - states are empty if file cannot be read
+func (l *configLoader) loadStates(stateFile string) {
+	err := l.changeStates.load(stateFile)
+	var versionErr *stateFileVersionError
+	switch {
+	case errors.As(err, &versionErr):
+		l.logger.Error("Unsupported version of state file - starting with empty state", "file", stateFile, "version", versionErr.version, "supported_version", stateFileVersion)
+	case err != nil:
+		l.logger.Error("Could not read state file - starting with empty state", "file", stateFile, "reason", err)
+	}
+}
+
+// write states to state file - file is replaced atomically
+func (s *changeStates) save(stateFile string) error {
+	s.saveMutex.Lock()
+	defer s.saveMutex.Unlock()
+	data, err := json.Marshal(s.toStateFile())
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(stateFile), "."+filepath.Base(stateFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), stateFile)
+}
+
+// write states to state file at each interval
+func (l *configLoader) saveStatesPeriodically(stateFile string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.saveStates(stateFile)
+	}
+}
+
+// write states to state file - errors are logged
+func (l *configLoader) saveStates(stateFile string) {
+	if err := l.changeStates.save(stateFile); err != nil {
+		l.logger.Error("Error writing state file", "file", stateFile, "reason", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateFile_ShouldRestoreSavedStates(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	states := newChangeStates()
+	key := changeKey{tree: "tree", path: "file.log"}
+	states.update(key, fileObservation{fileinfo: changedFile(time.Unix(1000, 0), 100), hasDigest: true, digest: 1})
+	states.update(key, fileObservation{fileinfo: changedFile(time.Unix(2000, 0), 50), hasDigest: true, digest: 2})
+	if err := states.save(stateFile); err != nil {
+		t.Fatal("Could not save state file:", err)
+	}
+
+	restored := newChangeStates()
+	if err := restored.load(stateFile); err != nil {
+		t.Fatal("Could not load state file:", err)
+	}
+	state := restored.update(key, fileObservation{fileinfo: changedFile(time.Unix(2000, 0), 50), hasDigest: true, digest: 3})
+	if state.modifications != 1 || state.truncations != 1 || state.contentChanges != 2 {
+		t.Errorf("Wrong counters after restore %+v", state)
+	}
+	if state.firstSeenNano != states.states[key].firstSeenNano {
+		t.Errorf("First seen time not restored")
+	}
+}
+
+func TestStateFile_ShouldIgnoreMissingFile(t *testing.T) {
+	states := newChangeStates()
+	if err := states.load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Error("Missing state file is an error:", err)
+	}
+}
+
+func TestStateFile_ShouldFailWithUnsupportedVersion(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(stateFile, []byte(`{"version":99,"files":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	states := newChangeStates()
+	if err := states.load(stateFile); err == nil {
+		t.Error("State file of unsupported version was loaded")
+	}
+}
+
+func TestLoadStates_ShouldStartWithEmptyStateWhenFileInvalid(t *testing.T) {
+	for content, expectedLog := range map[string]string{
+		`{"version":99,"files":[{"path":"file.log"}]}`: "version=99 supported_version=1",
+		`{"version":1,"files":[{"path":`:               "Could not read state file",
+	} {
+		stateFile := filepath.Join(t.TempDir(), "state.json")
+		if err := os.WriteFile(stateFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var logs bytes.Buffer
+		loader := newConfigLoader("none", &treeConfig{}, *slog.New(slog.NewTextHandler(&logs, nil)))
+		loader.changeStates.update(changeKey{path: "previous.log"}, fileObservation{fileinfo: changedFile(time.Unix(1000, 0), 100)})
+
+		loader.loadStates(stateFile)
+
+		if !strings.Contains(logs.String(), expectedLog) {
+			t.Errorf("Loading %s does not log %q: %s", content, expectedLog, logs.String())
+		}
+		if len(loader.changeStates.states) != 1 {
+			t.Errorf("States changed by loading %s: %v", content, loader.changeStates.states)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Pool bounding the number of tasks running in parallel
+//
+// A nil pool or a pool of size 1 runs tasks sequentially in order.
+type workerPool struct {
+	slots chan struct{}
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size <= 1 {
+		return nil
+	}
+	return &workerPool{slots: make(chan struct{}, size)}
+}
+
+// true if tasks are run in parallel
+func (p *workerPool) isParallel() bool {
+	return p != nil
+}
+
+// run task for each index - returns when all tasks are done
+//
+// Tasks from concurrent calls share the slots of the pool, a task must not call
+// forEach itself.
+func (p *workerPool) forEach(n int, task func(i int)) {
+	if !p.isParallel() || n <= 1 {
+		for i := 0; i < n; i++ {
+			task(i)
+		}
+		return
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(cap(p.slots), n) {
+		wg.Go(func() {
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				p.slots <- struct{}{}
+				task(i)
+				<-p.slots
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// run coordinating task for each index - tasks don't use slots but can call forEach
+func (p *workerPool) spawnEach(n int, task func(i int)) {
+	if !p.isParallel() || n <= 1 {
+		for i := 0; i < n; i++ {
+			task(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Go(func() { task(i) })
+	}
+	wg.Wait()
+}
@@ -0,0 +1,57 @@
+// Copyright 2019-2025 Michael DOUBEZ
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestWorkerPool_ShouldRunTasksSequentiallyWhenNil(t *testing.T) {
+	var pool *workerPool
+	order := []int{}
+
+	pool.forEach(3, func(i int) { order = append(order, i) })
+
+	if len(order) != 3 || order[0] != 0 || order[1] != 1 || order[2] != 2 {
+		t.Error("Tasks not run in order:", order)
+	}
+}
+
+func TestWorkerPool_ShouldBoundRunningTasks(t *testing.T) {
+	pool := newWorkerPool(2)
+	var running, maxRunning, done atomic.Int32
+
+	pool.spawnEach(3, func(int) {
+		pool.forEach(50, func(int) {
+			current := running.Add(1)
+			for {
+				previous := maxRunning.Load()
+				if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			running.Add(-1)
+			done.Add(1)
+		})
+	})
+
+	if done.Load() != 150 {
+		t.Error("Not all tasks run:", done.Load())
+	}
+	if maxRunning.Load() > 2 {
+		t.Error("Too many tasks running in parallel:", maxRunning.Load())
+	}
+}